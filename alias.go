@@ -3,9 +3,11 @@ package dix
 import (
 	"context"
 	"database/sql"
-	"encoding/binary"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
@@ -29,43 +31,373 @@ func IsUsername(s string) bool {
 	return true
 }
 
-func Resolve(db *sql.DB, username string, programID, rpcURL string) (solana.PublicKey, error) {
+// AliasTTL is how long a cached username is trusted before Resolve reads the
+// registry again.
+var AliasTTL = 24 * time.Hour
+
+// AliasVerifyAbove holds, per token, the raw amount from which a payment to a
+// username always re-reads the owner on chain, ignoring the cache.
+var AliasVerifyAbove = map[string]uint64{
+	"usdc": 100_000000,
+	"usdt": 100_000000,
+	"btc":  100000,
+	"ltc":  1_00000000,
+}
+
+// Where a recipient was resolved, as recorded on intents and in the cache.
+const (
+	SourceRegistry = "registry"
+	SourceSNS      = "sns"
+	SourcePubkey   = "pubkey"
+)
+
+// NameSource tells from its syntax how a recipient resolves: a raw pubkey, a
+// .sol domain or a dix username. It returns "" for anything else.
+func NameSource(s string) string {
+	if _, err := solana.PublicKeyFromBase58(s); err == nil {
+		return SourcePubkey
+	}
+	s = strings.ToLower(s)
+	switch {
+	case IsSNSDomain(s):
+		return SourceSNS
+	case IsUsername(s):
+		return SourceRegistry
+	}
+	return ""
+}
+
+// IsName reports whether s is a name that needs resolving (a dix username or
+// a .sol domain) rather than a pubkey.
+func IsName(s string) bool {
+	src := NameSource(s)
+	return src == SourceRegistry || src == SourceSNS
+}
+
+// Resolve returns the owner of a dix username, a .sol domain or, unchanged, a
+// pubkey. Names come from the cache while younger than AliasTTL.
+func Resolve(db *sql.DB, name string, programID, rpcURL string) (solana.PublicKey, error) {
+	if NameSource(name) == SourcePubkey {
+		return solana.PublicKeyFromBase58(name)
+	}
+	name = strings.ToLower(name)
+
+	cached, err := Getaliascache(db, name)
+	if err == nil && cached.Owner != "" && time.Since(time.Unix(cached.FetchedAt, 0)) < AliasTTL {
+		return solana.PublicKeyFromBase58(cached.Owner)
+	}
+
+	owner, ferr := ResolveOnChain(db, name, programID, rpcURL)
+	if ferr != nil && err == nil && cached.Owner != "" && !errors.Is(ferr, errAliasNotFound) {
+		return solana.PublicKeyFromBase58(cached.Owner)
+	}
+	return owner, ferr
+}
+
+// ResolveForAmount re-reads the chain when amount reaches the token's
+// AliasVerifyAbove threshold, and otherwise behaves like Resolve.
+func ResolveForAmount(db *sql.DB, name string, amount uint64, token string, programID, rpcURL string) (solana.PublicKey, error) {
+	if limit, ok := AliasVerifyAbove[token]; ok && amount >= limit && IsName(name) {
+		return ResolveOnChain(db, name, programID, rpcURL)
+	}
+	return Resolve(db, name, programID, rpcURL)
+}
+
+var errAliasNotFound = errors.New("username not found")
+
+// LookupAlias resolves username like Resolve and adds its profile when the
+// owner published one. A missing or unreadable profile is not an error.
+func LookupAlias(db *sql.DB, username string, programID, rpcURL string) (Alias, error) {
 	username = strings.ToLower(username)
 
-	cached, err := Getalias(db, username)
-	if err == nil && cached != "" {
-		return solana.PublicKeyFromBase58(cached)
+	owner, err := Resolve(db, username, programID, rpcURL)
+	if err != nil {
+		return Alias{}, err
+	}
+
+	a := Alias{Username: username, Owner: owner.String()}
+	if NameSource(username) != SourceRegistry {
+		return a, nil
+	}
+	if p, err := ReadProfile(username, programID, rpcURL); err == nil && p.Owner.Equals(owner) {
+		a.Profile = &Profile{
+			DisplayName:  p.DisplayName,
+			URL:          p.URL,
+			MemoRequired: p.MemoRequired,
+			UpdatedAt:    p.UpdatedAt,
+		}
+		if !p.Token.IsZero() {
+			a.Profile.Mint = p.Token.String()
+		}
+	}
+	return a, nil
+}
+
+func ReadProfile(username string, programID, rpcURL string) (ProfileAccount, error) {
+	if programID == "" {
+		return ProfileAccount{}, fmt.Errorf("registry program not deployed")
+	}
+
+	pda, err := ProfilePDA(solana.MustPublicKeyFromBase58(programID), strings.ToLower(username))
+	if err != nil {
+		return ProfileAccount{}, err
+	}
+
+	client := rpc.New(rpcURL)
+	acct, err := client.GetAccountInfo(context.Background(), pda)
+	if errors.Is(err, rpc.ErrNotFound) || (err == nil && acct.Value == nil) {
+		return ProfileAccount{}, fmt.Errorf("no profile for %s", username)
 	}
+	if err != nil {
+		return ProfileAccount{}, fmt.Errorf("profile %s: %w", username, err)
+	}
+	return DecodeProfile(acct.Value.Data.GetBinary())
+}
+
+// SetProfile publishes p as the profile of username. Only the alias owner can
+// sign it; an empty Mint clears the preferred token.
+func SetProfile(username string, p Profile, keypair solana.PrivateKey, programID, rpcURL string) (string, error) {
+	username = strings.ToLower(username)
+
+	if !IsUsername(username) {
+		return "", fmt.Errorf("invalid username: %s", username)
+	}
+	if programID == "" {
+		return "", fmt.Errorf("registry program not deployed")
+	}
+	if len(p.DisplayName) > 32 {
+		return "", fmt.Errorf("display name too long (max 32 bytes)")
+	}
+	if len(p.URL) > 100 {
+		return "", fmt.Errorf("url too long (max 100 bytes)")
+	}
+
+	acct := ProfileAccount{DisplayName: p.DisplayName, URL: p.URL, MemoRequired: p.MemoRequired}
+	if p.Mint != "" {
+		mint, err := solana.PublicKeyFromBase58(p.Mint)
+		if err != nil {
+			return "", fmt.Errorf("invalid mint: %s", p.Mint)
+		}
+		acct.Token = mint
+	}
+
+	program := solana.MustPublicKeyFromBase58(programID)
+	instruction, err := SetProfileInstruction(program, keypair.PublicKey(), username, acct)
+	if err != nil {
+		return "", err
+	}
+
+	recent, err := latestBlockhash(rpcURL)
+	if err != nil {
+		return "", err
+	}
+	tx, err := signInstructions([]solana.Instruction{instruction}, recent, keypair)
+	if err != nil {
+		return "", err
+	}
+
+	return submit(tx, rpcURL)
+}
+
+// ResolveOnChain reads the registry alias or the .sol name record and
+// refreshes the cache with the slot it was read at.
+func ResolveOnChain(db *sql.DB, name string, programID, rpcURL string) (solana.PublicKey, error) {
+	switch NameSource(name) {
+	case SourcePubkey:
+		return solana.PublicKeyFromBase58(name)
+	case SourceSNS:
+		return ResolveSNS(db, name, rpcURL)
+	case SourceRegistry:
+		return resolveRegistry(db, name, programID, rpcURL)
+	}
+	return solana.PublicKey{}, fmt.Errorf("invalid recipient: %s", name)
+}
+
+func resolveRegistry(db *sql.DB, username string, programID, rpcURL string) (solana.PublicKey, error) {
+	username = strings.ToLower(username)
 
 	if programID == "" {
 		return solana.PublicKey{}, fmt.Errorf("registry program not deployed")
 	}
 
 	program := solana.MustPublicKeyFromBase58(programID)
-	pda, _, err := solana.FindProgramAddress(
-		[][]byte{[]byte("alias"), []byte(username)},
-		program,
-	)
+	pda, err := AliasPDA(program, username)
 	if err != nil {
 		return solana.PublicKey{}, err
 	}
 
 	client := rpc.New(rpcURL)
 	acct, err := client.GetAccountInfo(context.Background(), pda)
-	if err != nil || acct.Value == nil {
-		return solana.PublicKey{}, fmt.Errorf("username not found: %s", username)
+	if errors.Is(err, rpc.ErrNotFound) || (err == nil && acct.Value == nil) {
+		return solana.PublicKey{}, fmt.Errorf("%w: %s", errAliasNotFound, username)
+	}
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("resolve %s: %w", username, err)
 	}
 
-	data := acct.Value.Data.GetBinary()
-	if len(data) < 40 {
-		return solana.PublicKey{}, fmt.Errorf("invalid account data")
+	alias, err := DecodeAlias(acct.Value.Data.GetBinary())
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("invalid account data: %w", err)
+	}
+	if alias.Username != username {
+		return solana.PublicKey{}, fmt.Errorf("alias account mismatch: %s", alias.Username)
 	}
 
-	owner := solana.PublicKeyFromBytes(data[8:40])
+	Savealiascache(db, AliasCache{
+		Username:  username,
+		Owner:     alias.Owner.String(),
+		FetchedAt: time.Now().Unix(),
+		Slot:      acct.Context.Slot,
+		Source:    SourceRegistry,
+	})
 
-	Savealias(db, username, owner.String())
+	return alias.Owner, nil
+}
 
-	return owner, nil
+// AliasAvailable reports whether username can still be registered. When it
+// is taken, the current owner is returned.
+func AliasAvailable(db *sql.DB, username string, programID, rpcURL string) (bool, solana.PublicKey, error) {
+	username = strings.ToLower(username)
+	if !IsUsername(username) {
+		return false, solana.PublicKey{}, fmt.Errorf("invalid username: use 3-20 lowercase letters/numbers")
+	}
+
+	owner, err := resolveRegistry(db, username, programID, rpcURL)
+	if errors.Is(err, errAliasNotFound) {
+		return true, solana.PublicKey{}, nil
+	}
+	if err != nil {
+		return false, solana.PublicKey{}, err
+	}
+	return false, owner, nil
+}
+
+// SearchAliases lists the registered usernames starting with prefix.
+func SearchAliases(db *sql.DB, prefix string, programID, rpcURL string) ([]AliasAccount, error) {
+	prefix = strings.ToLower(prefix)
+	var filters []rpc.RPCFilter
+	if prefix != "" {
+		filters = append(filters, rpc.RPCFilter{Memcmp: &rpc.RPCFilterMemcmp{Offset: 12, Bytes: solana.Base58(prefix)}})
+	}
+	return programAliases(db, programID, rpcURL, nil, filters...)
+}
+
+// AliasesOf lists the usernames owned by owner. The owner field sits after
+// the variable-length username, so it can't be a memcmp filter: the Alias
+// accounts are fetched in one call and filtered here.
+func AliasesOf(db *sql.DB, owner solana.PublicKey, programID, rpcURL string) ([]AliasAccount, error) {
+	return programAliases(db, programID, rpcURL, func(a AliasAccount) bool {
+		return a.Owner.Equals(owner)
+	})
+}
+
+// programAliases runs getProgramAccounts over the registry's Alias accounts
+// and refreshes the alias cache with every account it decodes and keeps
+// (all of them when keep is nil).
+func programAliases(db *sql.DB, programID, rpcURL string, keep func(AliasAccount) bool, filters ...rpc.RPCFilter) ([]AliasAccount, error) {
+	if programID == "" {
+		return nil, fmt.Errorf("registry program not deployed")
+	}
+
+	filters = append([]rpc.RPCFilter{
+		{Memcmp: &rpc.RPCFilterMemcmp{Offset: 0, Bytes: solana.Base58(accountDiscriminator("Alias"))}},
+	}, filters...)
+
+	// The typed GetProgramAccounts drops the response slot; withContext
+	// keeps it for the cache.
+	var res struct {
+		Context rpc.Context                  `json:"context"`
+		Value   rpc.GetProgramAccountsResult `json:"value"`
+	}
+	client := rpc.New(rpcURL)
+	err := client.RPCCallForInto(context.Background(), &res, "getProgramAccounts", []any{
+		solana.MustPublicKeyFromBase58(programID),
+		rpc.M{
+			"commitment":  rpc.CommitmentConfirmed,
+			"encoding":    solana.EncodingBase64,
+			"filters":     filters,
+			"withContext": true,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("registry accounts: %w", err)
+	}
+
+	now := time.Now().Unix()
+	var out []AliasAccount
+	for _, a := range res.Value {
+		alias, err := DecodeAlias(a.Account.Data.GetBinary())
+		if err != nil || (keep != nil && !keep(alias)) {
+			continue
+		}
+		Savealiascache(db, AliasCache{Username: alias.Username, Owner: alias.Owner.String(), FetchedAt: now, Slot: res.Context.Slot, Source: SourceRegistry})
+		out = append(out, alias)
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].Username < out[b].Username })
+	return out, nil
+}
+
+var errNoReverse = errors.New("no reverse record")
+
+// ReverseResolve returns the username whose reverse record points at pubkey.
+// A transfer can fill the empty reverse record of a wallet that did not sign,
+// so the name only counts if it still resolves to pubkey.
+// Lookups, including misses, are cached for AliasTTL. Without a registry
+// program only the local alias cache is consulted.
+func ReverseResolve(db *sql.DB, pubkey string, programID, rpcURL string) (string, error) {
+	username, fetchedAt, err := GetReverse(db, pubkey)
+	if err == nil && time.Since(time.Unix(fetchedAt, 0)) < AliasTTL {
+		if username == "" {
+			return "", errNoReverse
+		}
+		return username, nil
+	}
+
+	if programID == "" {
+		return Aliasof(db, pubkey)
+	}
+
+	owner, err := solana.PublicKeyFromBase58(pubkey)
+	if err != nil {
+		return "", err
+	}
+	pda, err := ReversePDA(solana.MustPublicKeyFromBase58(programID), owner)
+	if err != nil {
+		return "", err
+	}
+
+	client := rpc.New(rpcURL)
+	acct, err := client.GetAccountInfo(context.Background(), pda)
+	if errors.Is(err, rpc.ErrNotFound) || (err == nil && acct.Value == nil) {
+		SaveReverse(db, pubkey, "", time.Now().Unix())
+		return "", errNoReverse
+	}
+	if err != nil {
+		return "", fmt.Errorf("reverse %s: %w", pubkey, err)
+	}
+
+	reverse, err := DecodeReverse(acct.Value.Data.GetBinary())
+	if err != nil {
+		return "", fmt.Errorf("invalid account data: %w", err)
+	}
+	if !reverse.Owner.Equals(owner) {
+		reverse.Username = ""
+	}
+	if reverse.Username != "" {
+		current, err := resolveRegistry(db, reverse.Username, programID, rpcURL)
+		if err != nil && !errors.Is(err, errAliasNotFound) {
+			return "", err
+		}
+		if err != nil || !current.Equals(owner) {
+			reverse.Username = ""
+		}
+	}
+	SaveReverse(db, pubkey, reverse.Username, time.Now().Unix())
+
+	if reverse.Username == "" {
+		return "", errNoReverse
+	}
+	return reverse.Username, nil
 }
 
 func Register(db *sql.DB, username string, keypair solana.PrivateKey, programID, rpcURL string) (string, error) {
@@ -79,64 +411,141 @@ func Register(db *sql.DB, username string, keypair solana.PrivateKey, programID,
 		return "", fmt.Errorf("registry program not deployed")
 	}
 
-	client := rpc.New(rpcURL)
 	program := solana.MustPublicKeyFromBase58(programID)
 	user := keypair.PublicKey()
 
-	pda, bump, err := solana.FindProgramAddress(
-		[][]byte{[]byte("alias"), []byte(username)},
-		program,
-	)
+	instruction, err := RegisterInstruction(program, user, username)
 	if err != nil {
 		return "", err
 	}
 
-	discriminator := []byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
-	usernameBytes := []byte(username)
-	data := make([]byte, 8+4+len(usernameBytes)+1)
-	copy(data[:8], discriminator)
-	binary.LittleEndian.PutUint32(data[8:12], uint32(len(usernameBytes)))
-	copy(data[12:12+len(usernameBytes)], usernameBytes)
-	data[12+len(usernameBytes)] = bump
-
-	instruction := solana.NewInstruction(
-		program,
-		solana.AccountMetaSlice{
-			{PublicKey: pda, IsSigner: false, IsWritable: true},
-			{PublicKey: user, IsSigner: true, IsWritable: true},
-			{PublicKey: solana.SystemProgramID, IsSigner: false, IsWritable: false},
-		},
-		data,
-	)
+	recent, err := latestBlockhash(rpcURL)
+	if err != nil {
+		return "", err
+	}
+	tx, err := signInstructions([]solana.Instruction{instruction}, recent, keypair)
+	if err != nil {
+		return "", err
+	}
 
-	recent, err := client.GetLatestBlockhash(context.Background(), rpc.CommitmentFinalized)
+	sig, err := submit(tx, rpcURL)
 	if err != nil {
 		return "", err
 	}
-	tx, err := solana.NewTransaction(
-		[]solana.Instruction{instruction},
-		recent.Value.Blockhash,
-		solana.TransactionPayer(user),
-	)
+	Savealias(db, username, user.String())
+	SaveReverse(db, user.String(), username, time.Now().Unix())
+
+	return sig, nil
+}
+
+// TransferAlias moves username to newOwner with the registry's update
+// instruction. Only the current owner can sign it.
+func TransferAlias(db *sql.DB, username string, newOwner solana.PublicKey, keypair solana.PrivateKey, programID, rpcURL string) (string, error) {
+	username = strings.ToLower(username)
+
+	if !IsUsername(username) {
+		return "", fmt.Errorf("invalid username: %s", username)
+	}
+
+	if programID == "" {
+		return "", fmt.Errorf("registry program not deployed")
+	}
+
+	if newOwner.Equals(keypair.PublicKey()) {
+		return "", fmt.Errorf("%s already belongs to this wallet", username)
+	}
+
+	program := solana.MustPublicKeyFromBase58(programID)
+	instruction, err := UpdateInstruction(program, keypair.PublicKey(), username, newOwner)
 	if err != nil {
 		return "", err
 	}
 
-	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
-		if key.Equals(user) {
-			return &keypair
-		}
-		return nil
-	})
+	recent, err := latestBlockhash(rpcURL)
+	if err != nil {
+		return "", err
+	}
+	tx, err := signInstructions([]solana.Instruction{instruction}, recent, keypair)
 	if err != nil {
 		return "", err
 	}
 
-	sig, err := client.SendTransaction(context.Background(), tx)
+	sig, err := submit(tx, rpcURL)
 	if err != nil {
 		return "", err
 	}
-	Savealias(db, username, user.String())
+	DeleteAlias(db, username)
+	SaveReverse(db, keypair.PublicKey().String(), "", 0)
+	SaveReverse(db, newOwner.String(), "", 0)
+
+	return sig, nil
+}
+
+// ReleaseAlias closes the alias account of username and its profile, if
+// any, freeing the name for anyone to register and returning the rent of
+// both to the owner. It returns the signature and the lamports reclaimed.
+func ReleaseAlias(db *sql.DB, username string, keypair solana.PrivateKey, programID, rpcURL string) (string, uint64, error) {
+	username = strings.ToLower(username)
+
+	if !IsUsername(username) {
+		return "", 0, fmt.Errorf("invalid username: %s", username)
+	}
+
+	if programID == "" {
+		return "", 0, fmt.Errorf("registry program not deployed")
+	}
+
+	program := solana.MustPublicKeyFromBase58(programID)
+	pda, err := AliasPDA(program, username)
+	if err != nil {
+		return "", 0, err
+	}
+	profilePDA, err := ProfilePDA(program, username)
+	if err != nil {
+		return "", 0, err
+	}
+
+	client := rpc.New(rpcURL)
+	accts, err := client.GetMultipleAccounts(context.Background(), pda, profilePDA)
+	if err != nil {
+		return "", 0, fmt.Errorf("resolve %s: %w", username, err)
+	}
+	if len(accts.Value) != 2 || accts.Value[0] == nil {
+		return "", 0, fmt.Errorf("%w: %s", errAliasNotFound, username)
+	}
+	rent := accts.Value[0].Lamports
+	if accts.Value[1] != nil {
+		rent += accts.Value[1].Lamports
+	}
+
+	alias, err := DecodeAlias(accts.Value[0].Data.GetBinary())
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid account data: %w", err)
+	}
+	if !alias.Owner.Equals(keypair.PublicKey()) {
+		return "", 0, fmt.Errorf("%s belongs to %s", username, alias.Owner.String())
+	}
+
+	instruction, err := CloseInstruction(program, keypair.PublicKey(), username)
+	if err != nil {
+		return "", 0, err
+	}
+
+	recent, err := latestBlockhash(rpcURL)
+	if err != nil {
+		return "", 0, err
+	}
+	tx, err := signInstructions([]solana.Instruction{instruction}, recent, keypair)
+	if err != nil {
+		return "", 0, err
+	}
+
+	sig, err := submit(tx, rpcURL)
+	if err != nil {
+		return "", 0, err
+	}
+	DeleteAlias(db, username)
+	SaveReverse(db, keypair.PublicKey().String(), "", 0)
 
-	return sig.String(), nil
+	return sig, rent, nil
 }
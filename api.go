@@ -0,0 +1,229 @@
+package dix
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+//go:embed openapi.json
+var OpenAPI []byte
+
+type API struct {
+	DB       *sql.DB
+	Keypair  solana.PrivateKey
+	Token    string
+	Config   Config
+	Resolver Resolver
+	Prices   PriceSource
+
+	mu sync.Mutex
+}
+
+type payRequestBody struct {
+	To     string `json:"to"`
+	Token  string `json:"token"`
+	Amount string `json:"amount"`
+	Memo   string `json:"memo"`
+}
+
+type balanceBody struct {
+	Token    string `json:"token"`
+	Symbol   string `json:"symbol"`
+	Amount   uint64 `json:"amount"`
+	Decimals uint8  `json:"decimals"`
+	Display  string `json:"display"`
+	Error    string `json:"error,omitempty"`
+}
+
+func (a *API) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(OpenAPI)
+	})
+	mux.HandleFunc("POST /pay", a.auth(a.pay))
+	mux.HandleFunc("GET /balance", a.auth(a.balance))
+	mux.HandleFunc("GET /resolve/{username}", a.auth(a.resolve))
+	mux.HandleFunc("GET /ledger", a.auth(a.ledger))
+	mux.HandleFunc("GET /ledger/{id}", a.auth(a.intent))
+	mux.HandleFunc("GET /pools/{id}", a.auth(a.pool))
+	return mux
+}
+
+func (a *API) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(a.Token)) != 1 {
+			apiError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (a *API) pay(w http.ResponseWriter, r *http.Request) {
+	var body payRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		apiError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+
+	token := strings.ToLower(body.Token)
+	if _, ok := Tokens[token]; !ok {
+		apiError(w, http.StatusBadRequest, fmt.Errorf("token not supported: %s", body.Token))
+		return
+	}
+	amount, err := ParseAmount(body.Amount, token)
+	if err != nil {
+		apiError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := CheckMemo(body.Memo); err != nil {
+		apiError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	from := a.Keypair.PublicKey()
+	now := time.Now()
+	i := Intent{
+		ID:     mkid(from.String(), body.To, amount, now.Unix()),
+		From:   from.String(),
+		To:     body.To,
+		Amount: amount,
+		Token:  token,
+		Time:   now.Unix(),
+		Status: "pending",
+		Memo:   body.Memo,
+	}
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		h := sha256.Sum256([]byte("api:" + key))
+		i.ID = hex.EncodeToString(h[:])[:16]
+	}
+
+	a.mu.Lock()
+	i, err = PayIntent(a.DB, a.Keypair, i, a.Resolver, a.Prices, a.Config.RPC)
+	a.mu.Unlock()
+	if err != nil {
+		apiJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": err.Error(), "intent": i})
+		return
+	}
+
+	apiJSON(w, http.StatusOK, i)
+}
+
+func (a *API) balance(w http.ResponseWriter, r *http.Request) {
+	pubkey := a.Keypair.PublicKey()
+
+	keys := TokenKeys()
+	if t := strings.ToLower(r.URL.Query().Get("token")); t != "" {
+		if _, ok := Tokens[t]; !ok {
+			apiError(w, http.StatusBadRequest, fmt.Errorf("token not supported: %s", t))
+			return
+		}
+		keys = []string{t}
+	}
+
+	var out []balanceBody
+	for _, key := range keys {
+		b := balanceBody{Token: key, Symbol: GetTokenSymbol(key), Decimals: GetTokenDecimals(key)}
+		bal, err := Balance(pubkey, key, a.Config.RPC)
+		if err != nil {
+			b.Error = "no account"
+		} else {
+			b.Amount = bal
+		}
+		b.Display = FmtAmount(b.Amount, key)
+		out = append(out, b)
+	}
+
+	sol, err := SolBalance(pubkey, a.Config.RPC)
+	b := balanceBody{Token: "sol", Symbol: "SOL", Decimals: 9, Amount: sol, Display: FmtAmountDecimals(sol, 9)}
+	if err != nil {
+		b.Error = err.Error()
+	}
+	out = append(out, b)
+
+	apiJSON(w, http.StatusOK, map[string]any{"pubkey": pubkey.String(), "balances": out})
+}
+
+func (a *API) resolve(w http.ResponseWriter, r *http.Request) {
+	username := strings.ToLower(r.PathValue("username"))
+	if !IsName(username) {
+		apiError(w, http.StatusBadRequest, fmt.Errorf("invalid username or .sol domain: %s", username))
+		return
+	}
+
+	alias, err := LookupAlias(a.DB, username, a.Config.Program, a.Config.RPC)
+	if err != nil {
+		apiError(w, http.StatusNotFound, err)
+		return
+	}
+
+	apiJSON(w, http.StatusOK, alias)
+}
+
+func (a *API) ledger(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 || n > 1000 {
+			apiError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %s", l))
+			return
+		}
+		limit = n
+	}
+
+	intents, err := List(a.DB, limit)
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if intents == nil {
+		intents = []Intent{}
+	}
+
+	apiJSON(w, http.StatusOK, intents)
+}
+
+func (a *API) intent(w http.ResponseWriter, r *http.Request) {
+	i, err := Load(a.DB, r.PathValue("id"))
+	if err != nil {
+		apiError(w, http.StatusNotFound, fmt.Errorf("intent not found"))
+		return
+	}
+
+	apiJSON(w, http.StatusOK, i)
+}
+
+func (a *API) pool(w http.ResponseWriter, r *http.Request) {
+	p, members, err := PoolStatus(a.DB, r.PathValue("id"))
+	if err != nil {
+		apiError(w, http.StatusNotFound, fmt.Errorf("pool not found"))
+		return
+	}
+
+	apiJSON(w, http.StatusOK, map[string]any{"pool": p, "members": members})
+}
+
+func apiJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func apiError(w http.ResponseWriter, status int, err error) {
+	apiJSON(w, status, map[string]string{"error": err.Error()})
+}
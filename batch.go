@@ -0,0 +1,305 @@
+package dix
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+const maxTxSize = 1232
+
+// ReadBatch parses a batch file. Without an id the batch is named after the
+// file contents, so editing the file starts a new batch; pass the id printed
+// on the first run to resume an edited file.
+func ReadBatch(path, id string) (Batch, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Batch{}, err
+	}
+
+	if id == "" {
+		h := sha256.Sum256(data)
+		id = hex.EncodeToString(h[:])[:12]
+	}
+	b := Batch{ID: id, File: path}
+	seen := map[string]int{}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	var errs []string
+	for n := 0; ; n++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Batch{}, fmt.Errorf("csv: %w", err)
+		}
+		line, _ := r.FieldPos(0)
+
+		if n == 0 && strings.EqualFold(strings.TrimSpace(rec[0]), "to") {
+			continue
+		}
+		if len(rec) < 3 || len(rec) > 4 {
+			errs = append(errs, fmt.Sprintf("line %d: expected to,token,amount[,memo]", line))
+			continue
+		}
+
+		row := BatchRow{
+			Line:  line,
+			To:    strings.TrimSpace(rec[0]),
+			Token: strings.ToLower(strings.TrimSpace(rec[1])),
+		}
+		if len(rec) == 4 {
+			row.Memo = strings.TrimSpace(rec[3])
+		}
+
+		if _, ok := Tokens[row.Token]; !ok {
+			errs = append(errs, fmt.Sprintf("line %d: token not supported: %s", line, row.Token))
+			continue
+		}
+		row.Amount, err = ParseAmount(rec[2], row.Token)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+		if row.Amount == 0 {
+			errs = append(errs, fmt.Sprintf("line %d: amount cannot be zero", line))
+			continue
+		}
+		if IsName(row.To) {
+			row.To = strings.ToLower(row.To)
+		} else {
+			if _, err := solana.PublicKeyFromBase58(row.To); err != nil {
+				errs = append(errs, fmt.Sprintf("line %d: invalid recipient: %s", line, row.To))
+				continue
+			}
+			row.Resolved = row.To
+			row.Source = SourcePubkey
+		}
+		if err := CheckMemo(row.Memo); err != nil {
+			errs = append(errs, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+
+		key := fmt.Sprintf("%s|%s|%d|%s", row.To, row.Token, row.Amount, row.Memo)
+		row.Seq = seen[key]
+		seen[key]++
+
+		b.Rows = append(b.Rows, row)
+	}
+
+	if len(errs) > 0 {
+		return Batch{}, fmt.Errorf("invalid batch:\n  %s", strings.Join(errs, "\n  "))
+	}
+	if len(b.Rows) == 0 {
+		return Batch{}, fmt.Errorf("empty batch")
+	}
+
+	return b, nil
+}
+
+func BatchStatus(db *sql.DB, b *Batch) {
+	for k := range b.Rows {
+		row := &b.Rows[k]
+		if i, err := Load(db, mkBatchIntentID(b.ID, *row)); err == nil {
+			row.Intent = i
+		}
+	}
+}
+
+func ResolveBatch(db *sql.DB, b *Batch, r Resolver) error {
+	var errs []string
+	for k := range b.Rows {
+		row := &b.Rows[k]
+		if row.Intent.Status == "done" {
+			continue
+		}
+
+		if row.Resolved == "" {
+			if row.Intent.ToResolved != "" {
+				row.Resolved = row.Intent.ToResolved
+				row.Source = row.Intent.ToSource
+			} else {
+				pubkey, source, err := r.Resolve(row.To, row.Amount, row.Token)
+				if err != nil {
+					errs = append(errs, fmt.Sprintf("line %d: %v", row.Line, err))
+					continue
+				}
+				row.Resolved = pubkey.String()
+				row.Source = source
+				errors.As(CheckOwnerChange(db, row.To, pubkey), &row.Changed)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("cannot resolve batch:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return nil
+}
+
+func PayBatch(db *sql.DB, keypair solana.PrivateKey, b *Batch, prices PriceSource, rpcURL string) error {
+	from := keypair.PublicKey()
+	now := time.Now().Unix()
+
+	var pending []*BatchRow
+	for k := range b.Rows {
+		row := &b.Rows[k]
+		if row.Intent.Status == "done" {
+			continue
+		}
+		if row.Changed != nil {
+			row.Err = row.Changed
+			continue
+		}
+		i := Intent{
+			ID:         mkBatchIntentID(b.ID, *row),
+			From:       from.String(),
+			To:         row.To,
+			ToResolved: row.Resolved,
+			Amount:     row.Amount,
+			Token:      row.Token,
+			Time:       now,
+			Status:     "pending",
+			Memo:       row.Memo,
+			BatchID:    b.ID,
+			ToSource:   row.Source,
+		}
+
+		if existing, err := Load(db, i.ID); err == nil {
+			settled, err := Resume(db, existing, prices, rpcURL)
+			row.Intent, _ = Load(db, i.ID)
+			if err != nil {
+				row.Err = err
+				continue
+			}
+			if settled {
+				continue
+			}
+		}
+
+		row.Intent = i
+		row.Err = nil
+		if err := Save(db, i); err != nil {
+			return fmt.Errorf("save: %w", err)
+		}
+		pending = append(pending, row)
+	}
+
+	var groups [][]*BatchRow
+	var groupIxs [][]solana.Instruction
+	var cur []*BatchRow
+	var curIxs []solana.Instruction
+	for _, row := range pending {
+		ixs, err := transferInstructions(from, solana.MustPublicKeyFromBase58(row.Resolved), row.Intent)
+		if err != nil {
+			batchFail(db, []*BatchRow{row}, err)
+			continue
+		}
+
+		next := append(append([]solana.Instruction{}, curIxs...), ixs...)
+		size, err := txSize(next, from)
+		if err != nil {
+			batchFail(db, []*BatchRow{row}, err)
+			continue
+		}
+		if size > maxTxSize && len(cur) > 0 {
+			groups = append(groups, cur)
+			groupIxs = append(groupIxs, curIxs)
+			cur, next = nil, ixs
+		}
+		cur = append(cur, row)
+		curIxs = next
+	}
+	if len(cur) > 0 {
+		groups = append(groups, cur)
+		groupIxs = append(groupIxs, curIxs)
+	}
+
+	for g, rows := range groups {
+		fmt.Printf("tx %d/%d: %d transfers\n", g+1, len(groups), len(rows))
+
+		recent, err := latestBlockhash(rpcURL)
+		if err != nil {
+			batchFail(db, rows, err)
+			continue
+		}
+
+		tx, err := signInstructions(groupIxs[g], recent, keypair)
+		if err != nil {
+			batchFail(db, rows, err)
+			continue
+		}
+
+		sig := tx.Signatures[0].String()
+		for _, row := range rows {
+			row.Intent.Signature = sig
+			row.Intent.Time = time.Now().Unix()
+			Save(db, row.Intent)
+		}
+
+		if _, err := submit(tx, rpcURL); err != nil {
+			batchFail(db, rows, err)
+			continue
+		}
+		for _, row := range rows {
+			row.Intent.Status = "sent"
+			Save(db, row.Intent)
+		}
+		fmt.Printf("tx: %s\n", sig[:16]+"...")
+
+		if err := Confirm(sig, rpcURL, 30*time.Second); err != nil {
+			batchFail(db, rows, fmt.Errorf("confirm: %w", err))
+			continue
+		}
+		fee, _ := txFee(sig, rpcURL)
+		for n, row := range rows {
+			row.Intent.Status = "done"
+			if n == 0 {
+				row.Intent.Fee = fee
+			}
+			settle(db, row.Intent)
+			StampFiat(db, row.Intent, prices)
+		}
+	}
+
+	failed := 0
+	for _, row := range b.Rows {
+		if row.Intent.Status != "done" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d rows not settled, rerun with --batch-id %s to resume (also after editing the file)", failed, len(b.Rows), b.ID)
+	}
+	return nil
+}
+
+func batchFail(db *sql.DB, rows []*BatchRow, err error) {
+	for _, row := range rows {
+		row.Intent.Status = "fail"
+		row.Err = err
+		settle(db, row.Intent)
+	}
+}
+
+// mkBatchIntentID names a row by its content and how many identical rows
+// came before it, not by its line, so fixing or adding other rows does not
+// change the ids of rows already paid.
+func mkBatchIntentID(batchID string, row BatchRow) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("batch:%s:%s:%s:%d:%s:%d", batchID, row.To, row.Token, row.Amount, row.Memo, row.Seq)))
+	return hex.EncodeToString(h[:])[:16]
+}
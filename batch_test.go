@@ -0,0 +1,187 @@
+package dix
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func writeBatch(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "batch.csv")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadBatch(t *testing.T) {
+	pk := filled(7).String()
+	path := writeBatch(t,
+		"to,token,amount,memo",
+		"Joao,USDC,10,fatura 1",
+		pk+",usdt,0.5",
+		"joao,usdc,10,fatura 1",
+	)
+
+	b, err := ReadBatch(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.ID) != 12 || len(b.Rows) != 3 {
+		t.Fatalf("got id %q, %d rows", b.ID, len(b.Rows))
+	}
+
+	r := b.Rows[0]
+	if r.Line != 2 || r.To != "joao" || r.Token != "usdc" || r.Amount != 10_000_000 || r.Memo != "fatura 1" || r.Resolved != "" {
+		t.Errorf("row 0: %+v", r)
+	}
+	r = b.Rows[1]
+	if r.To != pk || r.Resolved != pk || r.Source != SourcePubkey || r.Amount != 500_000 || r.Memo != "" {
+		t.Errorf("row 1: %+v", r)
+	}
+	if b.Rows[0].Seq != 0 || b.Rows[2].Seq != 1 {
+		t.Errorf("repeated row seq: %d, %d", b.Rows[0].Seq, b.Rows[2].Seq)
+	}
+	if mkBatchIntentID(b.ID, b.Rows[0]) == mkBatchIntentID(b.ID, b.Rows[2]) {
+		t.Error("repeated rows share an intent id")
+	}
+
+	again, _ := ReadBatch(path, "")
+	if again.ID != b.ID {
+		t.Errorf("same file, different id: %s, %s", again.ID, b.ID)
+	}
+}
+
+func TestReadBatchEditKeepsIDs(t *testing.T) {
+	before, err := ReadBatch(writeBatch(t, "maria,usdc,1", "joao,usdc,2"), "payroll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := ReadBatch(writeBatch(t, "ana,usdc,3", "maria,usdc,1", "joao,usdc,2"), "payroll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.ID != "payroll" || after.ID != "payroll" {
+		t.Fatalf("explicit id not kept: %s, %s", before.ID, after.ID)
+	}
+	for k, row := range before.Rows {
+		if got, want := mkBatchIntentID(after.ID, after.Rows[k+1]), mkBatchIntentID(before.ID, row); got != want {
+			t.Errorf("%s: id changed after inserting a row", row.To)
+		}
+	}
+}
+
+func TestReadBatchErrors(t *testing.T) {
+	for name, lines := range map[string][]string{
+		"empty":        {"to,token,amount"},
+		"columns":      {"joao,usdc"},
+		"token":        {"joao,doge,1"},
+		"amount":       {"joao,usdc,abc"},
+		"zero":         {"joao,usdc,0"},
+		"recipient":    {"not a key,usdc,1"},
+		"memo too big": {"joao,usdc,1," + strings.Repeat("x", 600)},
+	} {
+		if _, err := ReadBatch(writeBatch(t, lines...), ""); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
+
+func TestPayBatchPacking(t *testing.T) {
+	rpc, rpcURL, keypair, db := payFixture(t)
+
+	var lines []string
+	for n := 0; n < 30; n++ {
+		var pk solana.PublicKey
+		pk[0], pk[1] = 0xaa, byte(n)
+		lines = append(lines, fmt.Sprintf("%s,usdc,%d,row %d", pk, n+1, n))
+	}
+	path := writeBatch(t, lines...)
+
+	b, err := ReadBatch(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	BatchStatus(db, &b)
+	if err := ResolveBatch(db, &b, StaticResolver{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := PayBatch(db, keypair, &b, nil, rpcURL); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rpc.sent) < 2 {
+		t.Fatalf("30 transfers packed into %d transaction(s)", len(rpc.sent))
+	}
+	transfers := 0
+	for _, tx := range rpc.sent {
+		data, err := tx.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) > maxTxSize {
+			t.Errorf("transaction of %d bytes", len(data))
+		}
+		for _, ix := range tx.Message.Instructions {
+			if tx.Message.AccountKeys[ix.ProgramIDIndex].Equals(solana.TokenProgramID) {
+				transfers++
+			}
+		}
+	}
+	if transfers != 30 {
+		t.Fatalf("sent %d transfers, want 30", transfers)
+	}
+	for _, row := range b.Rows {
+		if row.Intent.Status != "done" || row.Intent.Signature == "" {
+			t.Fatalf("line %d: status %s", row.Line, row.Intent.Status)
+		}
+	}
+
+	sent := len(rpc.sent)
+	rerun, _ := ReadBatch(path, "")
+	BatchStatus(db, &rerun)
+	if err := PayBatch(db, keypair, &rerun, nil, rpcURL); err != nil || len(rpc.sent) != sent {
+		t.Fatalf("rerun sent again: %v", err)
+	}
+}
+
+func TestPayBatchOwnerChanged(t *testing.T) {
+	rpc, rpcURL, keypair, db := payFixture(t)
+	before, after := filled(7), filled(8)
+	Save(db, Intent{ID: "earlier", To: "joao", ToResolved: before.String(), Amount: 1, Token: "usdc", Time: 1, Status: "done"})
+
+	b, err := ReadBatch(writeBatch(t, "joao,usdc,1", "maria,usdc,2"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	BatchStatus(db, &b)
+	if err := ResolveBatch(db, &b, StaticResolver{"joao": after, "maria": filled(6)}); err != nil {
+		t.Fatal(err)
+	}
+	if c := b.Rows[0].Changed; c == nil || !c.Last.Equals(before) || !c.Now.Equals(after) {
+		t.Fatalf("joao: want owner change, got %v", c)
+	}
+	if b.Rows[1].Changed != nil {
+		t.Fatalf("maria: unexpected owner change %v", b.Rows[1].Changed)
+	}
+
+	if err := PayBatch(db, keypair, &b, nil, rpcURL); err == nil {
+		t.Fatal("batch settled with an unconfirmed owner change")
+	}
+	if b.Rows[0].Intent.Status == "done" || b.Rows[1].Intent.Status != "done" || len(rpc.sent) != 1 {
+		t.Fatalf("got joao=%q maria=%q, %d sent", b.Rows[0].Intent.Status, b.Rows[1].Intent.Status, len(rpc.sent))
+	}
+
+	b.Rows[0].Changed = nil
+	if err := PayBatch(db, keypair, &b, nil, rpcURL); err != nil {
+		t.Fatal(err)
+	}
+	if b.Rows[0].Intent.Status != "done" || b.Rows[0].Intent.ToResolved != after.String() || len(rpc.sent) != 2 {
+		t.Fatalf("after confirming: status %q to %s, %d sent", b.Rows[0].Intent.Status, b.Rows[0].Intent.ToResolved, len(rpc.sent))
+	}
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"dix"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/spf13/cobra"
+)
+
+func aliasCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "alias",
+		Short: "manage your usernames",
+	}
+
+	cmd.AddCommand(aliasAvailableCmd())
+	cmd.AddCommand(aliasListCmd())
+	cmd.AddCommand(aliasSearchCmd())
+	cmd.AddCommand(aliasProfileCmd())
+	cmd.AddCommand(aliasTransferCmd())
+	cmd.AddCommand(aliasReleaseCmd())
+
+	return cmd
+}
+
+func aliasAvailableCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "available <username>",
+		Short: "check if a username can be registered",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			username := strings.ToLower(args[0])
+
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			ok, owner, err := dix.AliasAvailable(db, username, programID, rpcURL)
+			if err != nil {
+				die(err)
+			}
+
+			if ok {
+				fmt.Printf("%s is available: dix register %s\n", username, username)
+				return
+			}
+			fmt.Printf("%s is taken by %s\n", username, owner.String())
+		},
+	}
+}
+
+func aliasListCmd() *cobra.Command {
+	var owner string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list the usernames owned by a wallet (default: yours)",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			var pubkey solana.PublicKey
+			if owner != "" {
+				pubkey = lookupAddress(owner)
+			} else {
+				pubkey = ownPubkey()
+			}
+
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			aliases, err := dix.AliasesOf(db, pubkey, programID, rpcURL)
+			if err != nil {
+				die(err)
+			}
+
+			if len(aliases) == 0 {
+				fmt.Printf("no usernames for %s\n", pubkey.String())
+				return
+			}
+			printAliases(aliases)
+		},
+	}
+
+	cmd.Flags().StringVar(&owner, "owner", "", "pubkey, username or watch name")
+
+	return cmd
+}
+
+func aliasSearchCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "search <prefix>",
+		Short: "list registered usernames starting with prefix",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			aliases, err := dix.SearchAliases(db, args[0], programID, rpcURL)
+			if err != nil {
+				die(err)
+			}
+
+			if len(aliases) == 0 {
+				fmt.Println("no usernames found")
+				return
+			}
+			printAliases(aliases)
+		},
+	}
+}
+
+func printAliases(aliases []dix.AliasAccount) {
+	fmt.Printf("%-20s | %-44s | %s\n", "USERNAME", "OWNER", "REGISTERED")
+	fmt.Println(strings.Repeat("-", 86))
+
+	for _, a := range aliases {
+		fmt.Printf("%-20s | %-44s | %s\n",
+			a.Username,
+			a.Owner.String(),
+			time.Unix(a.CreatedAt, 0).Format("2006-01-02"),
+		)
+	}
+}
+
+func aliasProfileCmd() *cobra.Command {
+	var name, url, token string
+	var memoRequired bool
+
+	cmd := &cobra.Command{
+		Use:   "profile <username>",
+		Short: "show a username's profile, or edit yours with the flags",
+		Long:  "Examples:\n  dix alias profile joao\n  dix alias profile joao --name \"Joao Silva\" --url https://joao.dev --token usdt",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			username := strings.ToLower(args[0])
+			if !dix.IsUsername(username) {
+				die(fmt.Errorf("invalid username: %s", args[0]))
+			}
+
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			alias, err := dix.LookupAlias(db, username, programID, rpcURL)
+			if err != nil {
+				die(err)
+			}
+
+			flags := cmd.Flags()
+			if !flags.Changed("name") && !flags.Changed("url") && !flags.Changed("token") && !flags.Changed("memo-required") {
+				fmt.Printf("%s -> %s\n", alias.Username, alias.Owner)
+				if alias.Profile == nil {
+					fmt.Println("no profile")
+					return
+				}
+				printProfile(alias.Profile, "")
+				return
+			}
+
+			p := dix.Profile{}
+			if alias.Profile != nil {
+				p = *alias.Profile
+			}
+			if flags.Changed("name") {
+				p.DisplayName = name
+			}
+			if flags.Changed("url") {
+				p.URL = url
+			}
+			if flags.Changed("token") {
+				p.Mint = ""
+				if token != "" {
+					key := strings.ToLower(token)
+					if _, ok := dix.Tokens[key]; !ok {
+						die(fmt.Errorf("token not supported: %s", token))
+					}
+					p.Mint = dix.GetTokenMint(key)
+				}
+			}
+			if flags.Changed("memo-required") {
+				p.MemoRequired = memoRequired
+			}
+
+			pwd := readpwd("password: ")
+			secret, err := dix.Loadwallet(keypath, pwd)
+			if err != nil {
+				die(err)
+			}
+
+			sig, err := dix.SetProfile(username, p, dix.ToSolanaKey(secret), programID, rpcURL)
+			if err != nil {
+				die(err)
+			}
+
+			fmt.Printf("tx: %s\n", sig[:16]+"...")
+
+			if err := dix.Confirm(sig, rpcURL, 30*time.Second); err != nil {
+				die(err)
+			}
+
+			fmt.Printf("profile updated: %s\n", username)
+			printProfile(&p, "")
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "display name (max 32)")
+	cmd.Flags().StringVar(&url, "url", "", "link, e.g. avatar or site (max 100)")
+	cmd.Flags().StringVar(&token, "token", "", "preferred token to receive (empty to clear)")
+	cmd.Flags().BoolVar(&memoRequired, "memo-required", false, "ask payers for a memo")
+
+	return cmd
+}
+
+// printProfile shows p under the recipient line; token is what is being sent,
+// to flag a mismatch with the preferred one.
+func printProfile(p *dix.Profile, token string) {
+	if name := printable(p.DisplayName); name != "" {
+		fmt.Printf("name: %s\n", name)
+	}
+	if url := printable(p.URL); url != "" {
+		fmt.Printf("url: %s\n", url)
+	}
+	if p.Mint != "" {
+		preferred := p.Mint
+		if key, ok := dix.TokenByMint(p.Mint); ok {
+			preferred = dix.GetTokenSymbol(key)
+			if token != "" && key != token {
+				preferred += " (you are sending " + dix.GetTokenSymbol(token) + ")"
+			}
+		}
+		fmt.Printf("prefers: %s\n", preferred)
+	}
+	if p.MemoRequired {
+		fmt.Println("requires memo: yes")
+	}
+}
+
+// printable drops control and format runes (escape sequences, bidi
+// overrides) from profile text, which anyone can write on-chain.
+func printable(s string) string {
+	return strings.Map(func(r rune) rune {
+		if !unicode.IsPrint(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func aliasTransferCmd() *cobra.Command {
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "transfer <username> <new-owner>",
+		Short: "move a username to another wallet (e.g. after a key rotation)",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			username := strings.ToLower(args[0])
+			if !dix.IsUsername(username) {
+				die(fmt.Errorf("invalid username: %s", args[0]))
+			}
+			newOwner := lookupAddress(args[1])
+
+			fmt.Printf("transfer: %s -> %s\n", username, newOwner.String())
+			fmt.Printf("rpc: %s\n\n", rpcURL)
+			fmt.Println("payments to this username will go to the new owner. only the new owner can undo it.")
+			if !yes && !confirm("continue? [y/N] ") {
+				fmt.Println("aborted")
+				return
+			}
+
+			pwd := readpwd("password: ")
+			secret, err := dix.Loadwallet(keypath, pwd)
+			if err != nil {
+				die(err)
+			}
+
+			keypair := dix.ToSolanaKey(secret)
+
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			sig, err := dix.TransferAlias(db, username, newOwner, keypair, programID, rpcURL)
+			if err != nil {
+				die(err)
+			}
+
+			fmt.Printf("tx: %s\n", sig[:16]+"...")
+
+			if err := dix.Confirm(sig, rpcURL, 30*time.Second); err != nil {
+				die(err)
+			}
+
+			fmt.Printf("transferred: %s -> %s\n", username, newOwner.String())
+		},
+	}
+
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "skip confirmation")
+
+	return cmd
+}
+
+func aliasReleaseCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "release <username>",
+		Short: "give up a username and recover its rent",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			username := strings.ToLower(args[0])
+			if !dix.IsUsername(username) {
+				die(fmt.Errorf("invalid username: %s", args[0]))
+			}
+
+			fmt.Printf("release: %s\n", username)
+			fmt.Printf("rpc: %s\n\n", rpcURL)
+			fmt.Println("the username is deleted and anyone can register it again. payments to it will fail.")
+			fmt.Printf("type %s to confirm: ", username)
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			if strings.TrimSpace(answer) != username {
+				fmt.Println("aborted")
+				return
+			}
+
+			pwd := readpwd("password: ")
+			secret, err := dix.Loadwallet(keypath, pwd)
+			if err != nil {
+				die(err)
+			}
+
+			keypair := dix.ToSolanaKey(secret)
+
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			sig, rent, err := dix.ReleaseAlias(db, username, keypair, programID, rpcURL)
+			if err != nil {
+				die(err)
+			}
+
+			fmt.Printf("tx: %s\n", sig[:16]+"...")
+
+			if err := dix.Confirm(sig, rpcURL, 30*time.Second); err != nil {
+				die(err)
+			}
+
+			fmt.Printf("released: %s (+%s SOL rent)\n", username, dix.FmtAmountDecimals(rent, 9))
+		},
+	}
+}
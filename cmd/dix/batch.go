@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"dix"
+
+	"github.com/spf13/cobra"
+)
+
+func payBatchCmd() *cobra.Command {
+	var yes bool
+	var batchID string
+
+	cmd := &cobra.Command{
+		Use:   "batch <file.csv>",
+		Short: "pay every row of a CSV (to,token,amount,memo)",
+		Long:  "Rerunning the same file resumes it: rows already paid are skipped.\nAfter editing a partly paid file, pass the batch id from the first run so\npaid rows keep being skipped.\nExample: dix pay batch payouts.csv\n         dix pay batch payouts.csv --batch-id 3f9a0c1d2e4b",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			batch, err := dix.ReadBatch(args[0], batchID)
+			if err != nil {
+				die(err)
+			}
+			dir := loadContacts()
+
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			dix.BatchStatus(db, &batch)
+
+			fmt.Printf("batch: %s (%d rows)\n", batch.ID, len(batch.Rows))
+			fmt.Printf("rpc: %s\n\n", rpcURL)
+
+			if err := dix.ResolveBatch(db, &batch, dix.ResolverFor(db, dir, programID, rpcURL)); err != nil {
+				die(err)
+			}
+
+			fmt.Printf("%-5s | %-14s | %-12s | %16s | %-6s | %s\n", "LINE", "TO", "ADDRESS", "AMOUNT", "STATUS", "MEMO")
+			fmt.Println(strings.Repeat("-", 80))
+
+			var changed []*dix.BatchRow
+			for k, row := range batch.Rows {
+				status := row.Intent.Status
+				if status == "" {
+					status = "new"
+				}
+				address := truncTo(row.Resolved)
+				if row.Changed != nil {
+					address = "!" + address
+					changed = append(changed, &batch.Rows[k])
+				}
+				fmt.Printf("%-5d | %-14s | %-12s | %16s | %-6s | %s\n",
+					row.Line,
+					truncTo(row.To),
+					address,
+					dix.FmtAmount(row.Amount, row.Token)+" "+dix.GetTokenSymbol(row.Token),
+					status,
+					truncMemo(row.Memo),
+				)
+			}
+
+			if len(changed) > 0 {
+				fmt.Println("\n! name now resolves to a different wallet than the last payment")
+				for _, row := range changed {
+					fmt.Printf("line %d: warning: %v\n", row.Line, row.Changed)
+					if yes || confirm(fmt.Sprintf("line %d: pay the new owner? [y/N] ", row.Line)) {
+						row.Changed = nil
+					}
+				}
+			}
+
+			totals := map[string]uint64{}
+			todo := 0
+			for _, row := range batch.Rows {
+				if row.Intent.Status != "done" && row.Changed == nil {
+					totals[row.Token] += row.Amount
+					todo++
+				}
+			}
+
+			if todo == 0 {
+				if len(changed) == 0 {
+					fmt.Println("\nnothing to pay, batch already settled")
+				} else {
+					fmt.Println("\nnothing to pay")
+				}
+				return
+			}
+
+			fmt.Printf("\nto pay: %d rows\n", todo)
+			keys := make([]string, 0, len(totals))
+			for key := range totals {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				fmt.Printf("total: %s %s\n", dix.FmtAmount(totals[key], key), dix.GetTokenSymbol(key))
+			}
+
+			if !yes && !confirm("\nsend? [y/N] ") {
+				fmt.Println("aborted")
+				return
+			}
+
+			pwd := readpwd("password: ")
+			secret, err := dix.Loadwallet(keypath, pwd)
+			if err != nil {
+				die(err)
+			}
+
+			err = dix.PayBatch(db, dix.ToSolanaKey(secret), &batch, priceSource(db), rpcURL)
+
+			for _, row := range batch.Rows {
+				if row.Err != nil {
+					status := row.Intent.Status
+					if status == "" {
+						status = "skipped"
+					}
+					fmt.Printf("line %d: %s (%v)\n", row.Line, status, row.Err)
+				}
+			}
+			if err != nil {
+				die(err)
+			}
+
+			fmt.Printf("batch settled: %d rows\n", len(batch.Rows))
+		},
+	}
+
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "skip confirmation, also for names whose owner changed")
+	cmd.Flags().StringVar(&batchID, "batch-id", "", "resume this batch, even if the file was edited")
+
+	return cmd
+}
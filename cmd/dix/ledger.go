@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"dix"
+
+	"github.com/spf13/cobra"
+)
+
+type ledgerFlags struct {
+	from, to, token, status, party, min, max, search string
+}
+
+func (lf *ledgerFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&lf.from, "from", "", "first day (2006-01-02)")
+	cmd.Flags().StringVar(&lf.to, "to", "", "last day (2006-01-02)")
+	cmd.Flags().StringVar(&lf.token, "token", "", "only this token")
+	cmd.Flags().StringVar(&lf.status, "status", "", "only this status (pending, sent, done, fail)")
+	cmd.Flags().StringVar(&lf.party, "party", "", "counterparty username or pubkey")
+	cmd.Flags().StringVar(&lf.min, "min", "", "minimum amount (needs --token)")
+	cmd.Flags().StringVar(&lf.max, "max", "", "maximum amount (needs --token)")
+	cmd.Flags().StringVar(&lf.search, "search", "", "text in memo, id, signature or reference")
+}
+
+func (lf *ledgerFlags) filter() dix.LedgerFilter {
+	f := dix.LedgerFilter{Status: lf.status, Party: lf.party, Search: lf.search}
+	if lf.token != "" {
+		f.Token = strings.ToLower(lf.token)
+		if _, ok := dix.Tokens[f.Token]; !ok {
+			die(fmt.Errorf("token not supported: %s", lf.token))
+		}
+	}
+	if (lf.min != "" || lf.max != "") && f.Token == "" {
+		die(fmt.Errorf("--min/--max need --token"))
+	}
+	if lf.min != "" {
+		f.MinAmount = parseAmount(lf.min, f.Token)
+	}
+	if lf.max != "" {
+		f.MaxAmount = parseAmount(lf.max, f.Token)
+	}
+	if lf.from != "" {
+		f.Since = parseDay(lf.from).Unix()
+	}
+	if lf.to != "" {
+		f.Until = parseDay(lf.to).AddDate(0, 0, 1).Unix()
+	}
+	return f
+}
+
+func ledgerExportCmd() *cobra.Command {
+	var lf ledgerFlags
+	var format, output string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "export the ledger for accounting (csv, json, ofx)",
+		Long:  "Dates are local days, both inclusive.\nExample:\n  dix ledger export --format ofx --from 2026-03-01 --to 2026-03-31 -o march.ofx",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			f := lf.filter()
+
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			intents, err := dix.ListFiltered(db, f)
+			if err != nil {
+				die(err)
+			}
+
+			fiat, err := dix.FiatFor(db, intents)
+			if err != nil {
+				die(err)
+			}
+
+			var w io.Writer = os.Stdout
+			if output != "" {
+				file, err := os.Create(output)
+				if err != nil {
+					die(err)
+				}
+				defer file.Close()
+				w = file
+			}
+
+			switch strings.ToLower(format) {
+			case "csv":
+				err = dix.ExportCSV(w, intents, fiat)
+			case "json":
+				err = dix.ExportJSON(w, intents, fiat)
+			case "ofx":
+				err = dix.ExportOFX(w, intents, f)
+			default:
+				die(fmt.Errorf("unknown format: %s (csv, json, ofx)", format))
+			}
+			if err != nil {
+				die(err)
+			}
+
+			if output != "" {
+				fmt.Printf("exported %d intents to %s\n", len(intents), output)
+			}
+		},
+	}
+
+	lf.register(cmd)
+	cmd.Flags().StringVar(&format, "format", "csv", "csv, json or ofx")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "write to file instead of stdout")
+
+	return cmd
+}
+
+func ledgerShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <id-prefix>",
+		Short: "show one intent in full",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			i, err := dix.FindIntent(db, args[0])
+			if err != nil {
+				die(err)
+			}
+
+			field := func(name, value string) {
+				if value != "" {
+					fmt.Printf("%-12s %s\n", name+":", value)
+				}
+			}
+
+			field("id", i.ID)
+			field("direction", i.Direction)
+			field("status", i.Status)
+			field("amount", dix.FmtAmount(i.Amount, i.Token)+" "+dix.GetTokenSymbol(i.Token))
+			field("token mint", dix.GetTokenMint(i.Token))
+			field("from", i.From)
+			field("from alias", i.FromAlias)
+			field("to", i.To)
+			if i.ToResolved != i.To {
+				field("resolved", i.ToResolved)
+			}
+			field("resolved via", i.ToSource)
+			field("time", time.Unix(i.Time, 0).Format("2006-01-02 15:04:05"))
+			field("memo", i.Memo)
+			field("reference", i.Reference)
+			if i.PoolID != "" {
+				field("pool", fmt.Sprintf("%s round %d", i.PoolID, i.Round))
+			}
+			field("batch", i.BatchID)
+			field("schedule", i.ScheduleID)
+			if i.Fee > 0 {
+				field("fee", fmt.Sprintf("%.9f SOL", float64(i.Fee)/1e9))
+			}
+			stamps, _ := dix.ListFiat(db, i.ID)
+			for _, f := range stamps {
+				field("value "+f.Fiat, fmt.Sprintf("%s (@ %g)", dix.FmtFiat(f.Value, f.Fiat), f.Price))
+			}
+			field("signature", i.Signature)
+			if i.Signature != "" {
+				field("explorer", dix.ExplorerURL(i.Signature, rpcURL))
+			}
+		},
+	}
+}
+
+func parseDay(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		die(fmt.Errorf("invalid date %q, use 2006-01-02", s))
+	}
+	return t
+}
+
+func ledgerSyncCmd() *cobra.Command {
+	var full bool
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "import transfers from chain into the local ledger",
+		Long:  "Walks the history of your token accounts and records every transfer in or out.\nPayments already in the ledger are matched by signature, never duplicated.",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			owner := ownPubkey()
+
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			fmt.Printf("wallet: %s\n", owner.String())
+			fmt.Printf("rpc: %s\n\n", rpcURL)
+
+			res, err := dix.Sync(db, owner, full, programID, rpcURL)
+			fmt.Printf("scanned: %d\n", res.Scanned)
+			fmt.Printf("added: %d\n", res.Added)
+			fmt.Printf("updated: %d\n", res.Updated)
+			if err != nil {
+				die(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&full, "full", false, "rescan the whole history instead of only new signatures")
+
+	return cmd
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -23,6 +24,8 @@ var (
 	keypath    = filepath.Join(configDir, "keypair.json")
 	rpcURL     = dix.DevnetRPC
 	programID  = dix.RegistryProgram
+	priceURL   string
+	contacts   string
 )
 
 func main() {
@@ -32,15 +35,28 @@ func main() {
 	}
 
 	root.PersistentFlags().StringVar(&rpcURL, "rpc", dix.DevnetRPC, "Solana RPC URL")
+	root.PersistentFlags().StringVar(&priceURL, "price-url", os.Getenv("DIX_PRICE_URL"), "HTTP price source (default $DIX_PRICE_URL)")
+	root.PersistentFlags().DurationVar(&dix.AliasTTL, "alias-ttl", dix.AliasTTL, "how long a cached username is trusted")
+	root.PersistentFlags().StringVar(&contacts, "contacts", os.Getenv("DIX_CONTACTS"), "name,pubkey CSV checked before the registry (default $DIX_CONTACTS or ~/.dix/contacts.csv)")
 
 	root.AddCommand(initCmd())
 	root.AddCommand(registerCmd())
+	root.AddCommand(aliasCmd())
 	root.AddCommand(payCmd())
 	root.AddCommand(ledgerCmd())
 	root.AddCommand(balanceCmd())
 	root.AddCommand(recoverCmd())
+	root.AddCommand(migrateKeystoreCmd())
 	root.AddCommand(tokensCmd())
 	root.AddCommand(poolCmd())
+	root.AddCommand(requestCmd())
+	root.AddCommand(receiveCmd())
+	root.AddCommand(scheduleCmd())
+	root.AddCommand(serveCmd())
+	root.AddCommand(webhookCmd())
+	root.AddCommand(reconcileCmd())
+	root.AddCommand(pricesCmd())
+	root.AddCommand(watchCmd())
 
 	if err := root.Execute(); err != nil {
 		os.Exit(1)
@@ -111,6 +127,29 @@ func recoverCmd() *cobra.Command {
 	}
 }
 
+func migrateKeystoreCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate-keystore",
+		Short: "store the pubkey in an old keystore",
+		Long:  "Keystores from older versions only hold the encrypted keypair, so\nbalance and watch ask for the password. This rewrites the keystore with\nthe pubkey next to it.",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if pk, err := dix.Walletpubkey(keypath); err == nil {
+				fmt.Printf("already migrated: %s\n", pk)
+				return
+			}
+
+			pwd := readpwd("password: ")
+			pk, err := dix.MigrateWallet(keypath, pwd)
+			if err != nil {
+				die(err)
+			}
+			fmt.Printf("pubkey: %s\n", pk)
+			fmt.Printf("saved: %s\n", keypath)
+		},
+	}
+}
+
 func registerCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "register <username>",
@@ -159,12 +198,26 @@ func registerCmd() *cobra.Command {
 }
 
 func payCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "pay <token> <to> <amount>",
-		Short: "send tokens to username or pubkey",
-		Long:  "Tokens: usdc, usdt, btc, ltc\nExample: dix pay usdc joao 100",
-		Args:  cobra.ExactArgs(3),
+	var memo string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "pay <token> <to> <amount> | <solana-pay-url> [amount]",
+		Short: "send tokens to username, .sol domain or pubkey",
+		Long:  "Tokens: usdc, usdt, btc, ltc\nExample: dix pay usdc joao 100\n         dix pay 'solana:<pubkey>?amount=10&spl-token=<mint>'",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 && strings.HasPrefix(args[0], "solana:") {
+				return cobra.RangeArgs(1, 2)(cmd, args)
+			}
+			return cobra.ExactArgs(3)(cmd, args)
+		},
 		Run: func(cmd *cobra.Command, args []string) {
+			if strings.HasPrefix(args[0], "solana:") {
+				payURL(args, memo)
+				return
+			}
+			dir := loadContacts()
+
 			token := strings.ToLower(args[0])
 			to := args[1]
 			amount := parseAmount(args[2], token)
@@ -172,6 +225,9 @@ func payCmd() *cobra.Command {
 			if _, ok := dix.Tokens[token]; !ok {
 				die(fmt.Errorf("token not supported: %s (use: usdc, usdt, btc, ltc)", token))
 			}
+			if err := dix.CheckMemo(memo); err != nil {
+				die(err)
+			}
 
 			pwd := readpwd("password: ")
 			secret, err := dix.Loadwallet(keypath, pwd)
@@ -182,37 +238,174 @@ func payCmd() *cobra.Command {
 			keypair := dix.ToSolanaKey(secret)
 			from := keypair.PublicKey()
 
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
 			symbol := dix.GetTokenSymbol(token)
 			fmt.Printf("sending: %s %s\n", dix.FmtAmount(amount, token), symbol)
 			fmt.Printf("from: %s\n", from.String()[:12]+"...")
 			fmt.Printf("to: %s\n", to)
+			if dix.IsUsername(strings.ToLower(to)) && !inContacts(dir, to) {
+				if alias, err := dix.LookupAlias(db, to, programID, rpcURL); err == nil && alias.Profile != nil {
+					printProfile(alias.Profile, token)
+					if alias.Profile.MemoRequired && memo == "" {
+						die(fmt.Errorf("%s requires a memo: use --memo", to))
+					}
+				}
+			}
+			if memo != "" {
+				fmt.Printf("memo: %s\n", memo)
+			}
 			fmt.Printf("rpc: %s\n\n", rpcURL)
 
-			db, err := dix.Opendb(dbpath)
+			owner, source, err := dix.ResolverFor(db, dir, programID, rpcURL).Resolve(to, amount, token)
 			if err != nil {
-				die(err)
+				die(fmt.Errorf("resolve: %w", err))
+			}
+			if source != dix.SourcePubkey {
+				fmt.Printf("%s -> %s\n", to, owner.String()[:8]+"...")
+				if err := dix.CheckOwnerChange(db, to, owner); err != nil {
+					fmt.Printf("warning: %v\n", err)
+					if !yes && !confirm("send to the new owner? [y/N] ") {
+						die(fmt.Errorf("aborted"))
+					}
+				}
 			}
-			defer db.Close()
 
-			if err := dix.Pay(db, keypair, to, amount, token, programID, rpcURL); err != nil {
+			if err := dix.Pay(db, keypair, to, owner, source, amount, token, memo, priceSource(db), rpcURL); err != nil {
 				die(err)
 			}
 		},
 	}
+
+	cmd.Flags().StringVar(&memo, "memo", "", "attach a memo to the payment (e.g. \"invoice 42\")")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "pay even if the name now resolves to a different wallet")
+
+	cmd.AddCommand(payBatchCmd())
+
+	return cmd
+}
+
+// loadContacts reads the contacts file, or returns nil when there is none.
+// Only commands that resolve names call it, so a broken file does not stop
+// balance or init.
+func loadContacts() dix.Resolver {
+	path := contacts
+	if path == "" {
+		path = filepath.Join(configDir, "contacts.csv")
+		if _, err := os.Stat(path); err != nil {
+			return nil
+		}
+	}
+	c, err := dix.LoadContacts(path)
+	if err != nil {
+		die(err)
+	}
+	return c
+}
+
+// inContacts reports whether name is answered by the contacts file, which
+// shadows the registry user of the same name and their profile.
+func inContacts(dir dix.Resolver, name string) bool {
+	if dir == nil {
+		return false
+	}
+	_, _, err := dir.Resolve(name, 0, "")
+	return err == nil
+}
+
+// priceSource reads the local price table, then --price-url when set.
+func priceSource(db *sql.DB) dix.PriceSource {
+	var remote dix.PriceSource
+	if priceURL != "" {
+		remote = dix.HTTPPriceSource{URL: priceURL}
+	}
+	return dix.PriceSourceFor(db, remote)
+}
+
+func payURL(args []string, memo string) {
+	req, err := dix.ParsePayURL(args[0])
+	if err != nil {
+		die(err)
+	}
+	if memo != "" {
+		if req.Memo != "" && req.Memo != memo {
+			die(fmt.Errorf("payment url already sets memo: %s", req.Memo))
+		}
+		req.Memo = memo
+	}
+	if err := dix.CheckMemo(req.Memo); err != nil {
+		die(err)
+	}
+	if len(args) == 2 {
+		if req.Amount != 0 {
+			die(fmt.Errorf("payment url already sets amount"))
+		}
+		req.Amount = parseAmount(args[1], req.Token)
+	}
+	if req.Amount == 0 {
+		die(fmt.Errorf("payment url has no amount, pass it as second argument"))
+	}
+
+	pwd := readpwd("password: ")
+	secret, err := dix.Loadwallet(keypath, pwd)
+	if err != nil {
+		die(err)
+	}
+
+	keypair := dix.ToSolanaKey(secret)
+	from := keypair.PublicKey()
+
+	symbol := dix.GetTokenSymbol(req.Token)
+	fmt.Printf("sending: %s %s\n", dix.FmtAmount(req.Amount, req.Token), symbol)
+	fmt.Printf("from: %s\n", from.String()[:12]+"...")
+	fmt.Printf("to: %s\n", req.Recipient)
+	if req.Label != "" {
+		fmt.Printf("label: %s\n", req.Label)
+	}
+	if req.Message != "" {
+		fmt.Printf("message: %s\n", req.Message)
+	}
+	if req.Memo != "" {
+		fmt.Printf("memo: %s\n", req.Memo)
+	}
+	fmt.Printf("rpc: %s\n\n", rpcURL)
+
+	db, err := dix.Opendb(dbpath)
+	if err != nil {
+		die(err)
+	}
+	defer db.Close()
+
+	if err := dix.PayURL(db, keypair, req, priceSource(db), rpcURL); err != nil {
+		die(err)
+	}
 }
 
 func ledgerCmd() *cobra.Command {
-	return &cobra.Command{
+	var lf ledgerFlags
+	var limit int
+	var cursor string
+
+	cmd := &cobra.Command{
 		Use:   "ledger",
 		Short: "list transactions",
+		Long:  "Examples:\n  dix ledger --party joao --token usdc --min 10\n  dix ledger --from 2026-03-01 --to 2026-03-31 --status done\n  dix ledger --search aluguel",
+		Args:  cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
+			if limit <= 0 {
+				die(fmt.Errorf("--limit must be positive"))
+			}
 			db, err := dix.Opendb(dbpath)
 			if err != nil {
 				die(err)
 			}
 			defer db.Close()
 
-			intents, err := dix.List(db, 20)
+			intents, next, err := dix.Query(db, lf.filter(), cursor, limit)
 			if err != nil {
 				die(err)
 			}
@@ -222,8 +415,8 @@ func ledgerCmd() *cobra.Command {
 				return
 			}
 
-			fmt.Printf("%-10s | %-12s | %14s | %-6s | %s\n", "ID", "TO", "AMOUNT", "STATUS", "TIME")
-			fmt.Println(strings.Repeat("-", 65))
+			fmt.Printf("%-10s | %-3s | %-12s | %14s | %-6s | %-8s | %-16s | %s\n", "ID", "DIR", "PARTY", "AMOUNT", "STATUS", "TIME", "REF", "MEMO")
+			fmt.Println(strings.Repeat("-", 100))
 
 			now := time.Now().Unix()
 			for _, i := range intents {
@@ -233,50 +426,160 @@ func ledgerCmd() *cobra.Command {
 					token = "usdc"
 				}
 				symbol := dix.GetTokenSymbol(token)
-				fmt.Printf("%-10s | %-12s | %14s | %-6s | %s\n",
+				fmt.Printf("%-10s | %-3s | %-12s | %14s | %-6s | %-8s | %-16s | %s\n",
 					i.ID[:8],
-					truncTo(i.To),
+					i.Direction,
+					truncTo(partyName(db, i)),
 					dix.FmtAmount(i.Amount, token)+" "+symbol,
 					i.Status,
 					ago,
+					intentRef(i),
+					truncMemo(i.Memo),
 				)
 			}
+
+			if next != "" {
+				fmt.Printf("\nmore: dix ledger --cursor %s\n", next)
+			}
 		},
 	}
+
+	lf.register(cmd)
+	cmd.Flags().IntVar(&limit, "limit", 20, "rows per page")
+	cmd.Flags().StringVar(&cursor, "cursor", "", "continue from a previous page")
+
+	cmd.AddCommand(ledgerExportCmd())
+	cmd.AddCommand(ledgerShowCmd())
+	cmd.AddCommand(ledgerSyncCmd())
+
+	return cmd
 }
 
 func balanceCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "balance",
-		Short: "check token balances",
+	var fiat string
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "balance [pubkey|username|watch-name]",
+		Short: "check token balances (no password needed)",
+		Args:  cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			pwd := readpwd("password: ")
-			secret, err := dix.Loadwallet(keypath, pwd)
-			if err != nil {
-				die(err)
+			if all {
+				portfolio(strings.ToLower(fiat))
+				return
 			}
 
-			pubkey := solana.PublicKey(secret[32:64])
+			var pubkey solana.PublicKey
+			if len(args) == 1 {
+				pubkey = lookupAddress(args[0])
+			} else {
+				pubkey = ownPubkey()
+			}
 			fmt.Printf("pubkey: %s\n", pubkey.String())
 			fmt.Printf("rpc: %s\n\n", rpcURL)
 
-			for key, info := range dix.Tokens {
-				bal, err := dix.Balance(pubkey, key, rpcURL)
-				if err != nil {
-					fmt.Printf("%s: (no account)\n", info.Symbol)
-				} else {
-					fmt.Printf("%s: %s\n", info.Symbol, dix.FmtAmount(bal, key))
+			var value fiatTotal
+			if fiat != "" {
+				value = newFiatTotal(strings.ToLower(fiat))
+				defer value.close()
+			}
+
+			holdings, herr := dix.Holdings(pubkey, rpcURL)
+			if herr != nil {
+				fmt.Printf("holdings scan failed (%v), showing supported tokens only\n\n", herr)
+				for _, key := range dix.TokenKeys() {
+					bal, err := dix.Balance(pubkey, key, rpcURL)
+					if err != nil {
+						fmt.Printf("%s: (no account)\n", dix.GetTokenSymbol(key))
+					} else {
+						fmt.Printf("%s: %s%s\n", dix.GetTokenSymbol(key), dix.FmtAmount(bal, key), value.add(bal, key))
+					}
 				}
+			} else {
+				printHoldings(holdings, &value)
 			}
 
 			sol, err := dix.SolBalance(pubkey, rpcURL)
 			if err != nil {
 				fmt.Printf("SOL: (error)\n")
 			} else {
-				fmt.Printf("SOL: %.6f\n", float64(sol)/1e9)
+				fmt.Printf("SOL: %.6f%s\n", float64(sol)/1e9, value.add(sol, "sol"))
+			}
+
+			if herr == nil {
+				printAccounts(holdings)
 			}
+
+			value.print()
 		},
 	}
+
+	cmd.Flags().StringVar(&fiat, "fiat", "", "also show value in this currency (usd, brl)")
+	cmd.Flags().BoolVar(&all, "all", false, "portfolio: your wallet plus every watched address")
+
+	return cmd
+}
+
+func printHoldings(holdings []dix.Holding, value *fiatTotal) {
+	totals := map[string]uint64{}
+	for _, h := range holdings {
+		if h.Token != "" {
+			totals[h.Token] += h.Amount
+		}
+	}
+	for _, key := range dix.TokenKeys() {
+		bal, ok := totals[key]
+		if !ok {
+			fmt.Printf("%s: (no account)\n", dix.GetTokenSymbol(key))
+			continue
+		}
+		fmt.Printf("%s: %s%s\n", dix.GetTokenSymbol(key), dix.FmtAmount(bal, key), value.add(bal, key))
+	}
+
+	printed := map[string]bool{}
+	for _, h := range holdings {
+		if h.Token != "" || printed[h.Mint] {
+			continue
+		}
+		var total uint64
+		for _, o := range holdings {
+			if o.Mint == h.Mint {
+				total += o.Amount
+			}
+		}
+		printed[h.Mint] = true
+		fmt.Printf("%s: %s (unknown mint %s, %d decimals)\n", h.Symbol(), dix.FmtAmountDecimals(total, h.Decimals), h.Mint, h.Decimals)
+	}
+}
+
+func printAccounts(holdings []dix.Holding) {
+	var notes []string
+	for _, h := range holdings {
+		var flags []string
+		if !h.IsATA {
+			flags = append(flags, "not ATA")
+		}
+		if h.Program == "token-2022" {
+			flags = append(flags, "token-2022")
+		}
+		if h.Frozen {
+			flags = append(flags, "FROZEN")
+		}
+		if h.Delegate != "" {
+			flags = append(flags, fmt.Sprintf("delegate %s may move %s", truncTo(h.Delegate), dix.FmtAmountDecimals(h.DelegatedAmount, h.Decimals)))
+		}
+		if len(flags) == 0 {
+			continue
+		}
+		notes = append(notes, fmt.Sprintf("  %s %s %s [%s]", h.Account, h.Symbol(), h.Display(), strings.Join(flags, ", ")))
+	}
+	if len(notes) == 0 {
+		return
+	}
+	fmt.Println("\ntoken accounts:")
+	for _, n := range notes {
+		fmt.Println(n)
+	}
 }
 
 func tokensCmd() *cobra.Command {
@@ -286,7 +589,8 @@ func tokensCmd() *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 			fmt.Printf("%-6s | %-6s | %s\n", "KEY", "SYMBOL", "MINT")
 			fmt.Println(strings.Repeat("-", 60))
-			for key, info := range dix.Tokens {
+			for _, key := range dix.TokenKeys() {
+				info := dix.Tokens[key]
 				fmt.Printf("%-6s | %-6s | %s\n", key, info.Symbol, info.Mint[:16]+"...")
 			}
 		},
@@ -306,6 +610,7 @@ func poolCmd() *cobra.Command {
 	cmd.AddCommand(poolClaimCmd())
 	cmd.AddCommand(poolStatusCmd())
 	cmd.AddCommand(poolListCmd())
+	cmd.AddCommand(poolHistoryCmd())
 
 	return cmd
 }
@@ -437,7 +742,7 @@ func poolPayCmd() *cobra.Command {
 			symbol := dix.GetTokenSymbol(pool.Token)
 			fmt.Printf("paying: %s %s to round %d winner\n", dix.FmtAmount(pool.Contribution, pool.Token), symbol, pool.Round)
 
-			err = dix.ContributePool(db, poolID, username, keypair, rpcURL)
+			err = dix.ContributePool(db, poolID, username, keypair, priceSource(db), rpcURL)
 			if err != nil {
 				die(err)
 			}
@@ -521,7 +826,11 @@ func poolStatusCmd() *cobra.Command {
 				if m.Order == pool.Round-1 {
 					winner = " <-- winner"
 				}
-				fmt.Printf("%-4d | %-14s | %-6s | %-7s%s\n", m.Order+1, m.Username, paid, claimed, winner)
+				member := m.Username
+				if name := reverseName(db, m.Pubkey); name != "" {
+					member = name
+				}
+				fmt.Printf("%-4d | %-14s | %-6s | %-7s%s\n", m.Order+1, member, paid, claimed, winner)
 			}
 		},
 	}
@@ -565,11 +874,75 @@ func poolListCmd() *cobra.Command {
 	}
 }
 
+func poolHistoryCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "history <pool-id>",
+		Short: "show pool event timeline",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			poolID := args[0]
+
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			pool, events, err := dix.PoolHistory(db, poolID)
+			if err != nil {
+				die(err)
+			}
+
+			fmt.Printf("Pool: %s (%s)\n\n", pool.Name, pool.ID)
+
+			if len(events) == 0 {
+				fmt.Println("no events")
+				return
+			}
+
+			fmt.Printf("%-16s | %-5s | %-10s | %-14s | %s\n", "TIME", "ROUND", "EVENT", "MEMBER", "TX")
+			fmt.Println(strings.Repeat("-", 75))
+
+			for _, e := range events {
+				round := "-"
+				if e.Round > 0 {
+					round = strconv.Itoa(e.Round)
+				}
+				member := e.Username
+				if member == "" {
+					member = "-"
+				}
+				tx := "-"
+				if e.Signature != "" {
+					tx = e.Signature[:16] + "..."
+				}
+				fmt.Printf("%-16s | %-5s | %-10s | %-14s | %s\n",
+					time.Unix(e.Time, 0).Format("2006-01-02 15:04"),
+					round,
+					e.Kind,
+					member,
+					tx,
+				)
+			}
+		},
+	}
+}
+
 func die(err error) {
 	fmt.Fprintf(os.Stderr, "error: %v\n", err)
 	os.Exit(1)
 }
 
+// readpwdEnv lets unattended commands (serve, schedule run) take the
+// password from DIX_PASSWORD. Interactive commands always prompt, so a
+// password left in the environment does not sign pays or alias changes.
+func readpwdEnv(prompt string) []byte {
+	if pwd := os.Getenv("DIX_PASSWORD"); pwd != "" {
+		return []byte(pwd)
+	}
+	return readpwd(prompt)
+}
+
 func readpwd(prompt string) []byte {
 	fmt.Print(prompt)
 	pwd, _ := term.ReadPassword(int(os.Stdin.Fd()))
@@ -577,30 +950,20 @@ func readpwd(prompt string) []byte {
 	return pwd
 }
 
-func parseAmount(s string, token string) uint64 {
-	s = strings.TrimSpace(s)
-	decimals := dix.GetTokenDecimals(token)
-	multiplier := uint64(1)
-	for i := uint8(0); i < decimals; i++ {
-		multiplier *= 10
-	}
-
-	if strings.Contains(s, ".") {
-		parts := strings.Split(s, ".")
-		whole, _ := strconv.ParseUint(parts[0], 10, 64)
-		frac := parts[1]
-
-		for len(frac) < int(decimals) {
-			frac += "0"
-		}
-		frac = frac[:decimals]
+func confirm(prompt string) bool {
+	fmt.Print(prompt)
+	reader := bufio.NewReader(os.Stdin)
+	answer, _ := reader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
 
-		fracN, _ := strconv.ParseUint(frac, 10, 64)
-		return whole*multiplier + fracN
+func parseAmount(s string, token string) uint64 {
+	amount, err := dix.ParseAmount(s, token)
+	if err != nil {
+		die(err)
 	}
-
-	n, _ := strconv.ParseUint(s, 10, 64)
-	return n * multiplier
+	return amount
 }
 
 func truncTo(s string) string {
@@ -616,6 +979,69 @@ func truncTo(s string) string {
 	return s
 }
 
+func party(i dix.Intent) string {
+	if i.Direction == "in" {
+		if i.FromAlias != "" {
+			return i.FromAlias
+		}
+		return i.From
+	}
+	return i.To
+}
+
+// partyName prefers the username from the counterparty's reverse record over
+// a bare pubkey.
+func partyName(db *sql.DB, i dix.Intent) string {
+	p := party(i)
+	if dix.IsName(p) {
+		return p
+	}
+	if name := reverseName(db, p); name != "" {
+		return name
+	}
+	return p
+}
+
+var reverseNames = map[string]string{}
+
+func reverseName(db *sql.DB, pubkey string) string {
+	if pubkey == "" {
+		return ""
+	}
+	if name, ok := reverseNames[pubkey]; ok {
+		return name
+	}
+	name, _ := dix.ReverseResolve(db, pubkey, programID, rpcURL)
+	reverseNames[pubkey] = name
+	return name
+}
+
+func intentRef(i dix.Intent) string {
+	if i.PoolID != "" {
+		return fmt.Sprintf("pool %s r%d", i.PoolID, i.Round)
+	}
+	if i.Reference != "" {
+		return "req " + i.Reference[:8]
+	}
+	if i.BatchID != "" {
+		return "batch " + i.BatchID
+	}
+	if i.ScheduleID != "" {
+		return "sched " + i.ScheduleID
+	}
+	return "-"
+}
+
+func truncMemo(s string) string {
+	if s == "" {
+		return "-"
+	}
+	if r := []rune(s); len(r) > 24 {
+		return string(r[:21]) + "..."
+	}
+	return s
+}
+
 func fmtAgo(secs int64) string {
 	if secs < 60 {
 		return fmt.Sprintf("%ds ago", secs)
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"dix"
+
+	"github.com/spf13/cobra"
+)
+
+func pricesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prices",
+		Short: "fiat prices used to value payments",
+	}
+
+	cmd.AddCommand(pricesImportCmd())
+	cmd.AddCommand(pricesGetCmd())
+	cmd.AddCommand(pricesBackfillCmd())
+
+	return cmd
+}
+
+func pricesImportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import <file.csv>",
+		Short: "load a price table (token,fiat,time,price)",
+		Long:  "Example:\n  token,fiat,time,price\n  usdc,brl,2026-03-01,5.71\n  btc,usd,2026-03-01T12:00:00Z,88000",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			f, err := os.Open(args[0])
+			if err != nil {
+				die(err)
+			}
+			defer f.Close()
+
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			n, err := dix.ImportPrices(db, f)
+			if err != nil {
+				die(err)
+			}
+
+			fmt.Printf("imported: %d prices\n", n)
+		},
+	}
+}
+
+func pricesGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <token> <fiat> [2006-01-02]",
+		Short: "show the price used for a day (default now)",
+		Args:  cobra.RangeArgs(2, 3),
+		Run: func(cmd *cobra.Command, args []string) {
+			token := strings.ToLower(args[0])
+			fiat := strings.ToLower(args[1])
+			at := time.Now()
+			if len(args) == 3 {
+				at = parseDay(args[2]).AddDate(0, 0, 1).Add(-time.Second)
+			}
+
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			price, err := priceSource(db).Price(token, fiat, at)
+			if err != nil {
+				die(err)
+			}
+
+			fmt.Printf("1 %s = %g %s\n", strings.ToUpper(token), price, strings.ToUpper(fiat))
+		},
+	}
+}
+
+func pricesBackfillCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "backfill",
+		Short: "value settled payments that have no fiat value yet",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			intents, err := dix.ListFiltered(db, dix.LedgerFilter{Status: "done"})
+			if err != nil {
+				die(err)
+			}
+
+			src := priceSource(db)
+			stamped, missing := 0, 0
+			for _, i := range intents {
+				if err := dix.StampFiat(db, i, src); err != nil {
+					missing++
+					fmt.Printf("%s: %v\n", i.ID[:8], err)
+					continue
+				}
+				stamped++
+			}
+
+			fmt.Printf("\nvalued: %d\n", stamped)
+			fmt.Printf("missing prices: %d\n", missing)
+		},
+	}
+}
+
+type fiatTotal struct {
+	fiat  string
+	db    *sql.DB
+	src   dix.PriceSource
+	total int64
+	gaps  int
+}
+
+func newFiatTotal(fiat string) fiatTotal {
+	db, err := dix.Opendb(dbpath)
+	if err != nil {
+		die(err)
+	}
+	return fiatTotal{fiat: fiat, db: db, src: priceSource(db)}
+}
+
+func (t *fiatTotal) add(amount uint64, token string) string {
+	if t.src == nil {
+		return ""
+	}
+	price, err := t.src.Price(token, t.fiat, time.Now())
+	if err != nil {
+		t.gaps++
+		return "  (no price)"
+	}
+	v := dix.FiatValue(amount, token, price)
+	t.total += v
+	return "  ~ " + dix.FmtFiat(v, t.fiat)
+}
+
+func (t *fiatTotal) print() {
+	if t.src == nil {
+		return
+	}
+	fmt.Printf("\ntotal: %s", dix.FmtFiat(t.total, t.fiat))
+	if t.gaps > 0 {
+		fmt.Printf(" (%d without price)", t.gaps)
+	}
+	fmt.Println()
+}
+
+func (t *fiatTotal) close() {
+	if t.db != nil {
+		t.db.Close()
+	}
+}
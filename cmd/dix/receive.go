@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"dix"
+
+	"github.com/spf13/cobra"
+)
+
+func receiveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "receive",
+		Short: "detect incoming payments",
+	}
+
+	cmd.AddCommand(receiveWatchCmd())
+
+	return cmd
+}
+
+func receiveWatchCmd() *cobra.Command {
+	var interval time.Duration
+	var once bool
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "poll your token accounts and record incoming payments",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			owner := ownPubkey()
+
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			fmt.Printf("watching: %s\n", owner.String())
+			fmt.Printf("rpc: %s\n\n", rpcURL)
+
+			for {
+				got, err := dix.Receive(db, owner, priceSource(db), programID, rpcURL)
+				for _, i := range got {
+					printIncoming(i)
+				}
+				if err != nil {
+					if once {
+						die(err)
+					}
+					fmt.Printf("error: %v\n", err)
+				}
+				if _, err := dix.DeliverWebhooks(db); err != nil {
+					fmt.Printf("webhooks: %v\n", err)
+				}
+				if once {
+					return
+				}
+				time.Sleep(interval)
+			}
+		},
+	}
+
+	cmd.Flags().DurationVar(&interval, "interval", 15*time.Second, "polling interval")
+	cmd.Flags().BoolVar(&once, "once", false, "poll once and exit")
+
+	return cmd
+}
+
+func printIncoming(i dix.Intent) {
+	from := i.FromAlias
+	if from == "" {
+		from = truncTo(i.From)
+	}
+	line := fmt.Sprintf("%s  +%s %s from %s",
+		time.Unix(i.Time, 0).Format("2006-01-02 15:04"),
+		dix.FmtAmount(i.Amount, i.Token),
+		dix.GetTokenSymbol(i.Token),
+		from,
+	)
+	if i.Reference != "" {
+		line += " (request " + i.Reference[:8] + ")"
+	}
+	if i.Memo != "" {
+		line += fmt.Sprintf(" memo: %q", i.Memo)
+	}
+	fmt.Println(line)
+}
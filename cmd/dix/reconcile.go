@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"dix"
+
+	"github.com/spf13/cobra"
+)
+
+func reconcileCmd() *cobra.Command {
+	var accept, history bool
+
+	cmd := &cobra.Command{
+		Use:   "reconcile",
+		Short: "compare the ledger with on-chain balances",
+		Long:  "Expected balance = last checkpoint + in - out since then.\nA clean run records a new checkpoint; --accept records one even with differences.",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if history {
+				reconcileHistory()
+				return
+			}
+
+			owner := ownPubkey()
+
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			fmt.Printf("wallet: %s\n", owner.String())
+			fmt.Printf("rpc: %s\n\n", rpcURL)
+
+			report, err := dix.Reconcile(db, owner, rpcURL)
+			if err != nil {
+				die(err)
+			}
+
+			fmt.Printf("%-6s | %-16s | %16s | %16s | %16s | %s\n", "TOKEN", "SINCE", "EXPECTED", "ACTUAL", "DIFF", "")
+			fmt.Println(strings.Repeat("-", 90))
+			for _, row := range report.Rows {
+				since := "-"
+				if row.Since > 0 {
+					since = time.Unix(row.Since, 0).Format("2006-01-02 15:04")
+				}
+				note := "ok"
+				switch {
+				case row.Err != nil:
+					note = "error: " + row.Err.Error()
+				case row.Diff() != 0:
+					note = "MISMATCH"
+				}
+				fmt.Printf("%-6s | %-16s | %16s | %16s | %16s | %s\n",
+					reconcileSymbol(row.Token),
+					since,
+					reconcileAmount(row.Expected, row.Token),
+					reconcileAmount(int64(row.Actual), row.Token),
+					reconcileAmount(row.Diff(), row.Token),
+					note,
+				)
+			}
+
+			if len(report.Unmatched) > 0 {
+				fmt.Printf("\non chain, not in ledger (%d):\n", len(report.Unmatched))
+				for _, t := range report.Unmatched {
+					fmt.Printf("  %s %-3s %s %s %s\n",
+						time.Unix(t.Time, 0).Format("2006-01-02 15:04"),
+						t.Direction,
+						dix.FmtAmount(t.Amount, t.Token),
+						dix.GetTokenSymbol(t.Token),
+						t.Signature,
+					)
+				}
+				fmt.Println("  run `dix ledger sync` to import them")
+			}
+
+			if len(report.Unconfirmed) > 0 {
+				fmt.Printf("\nin ledger, not confirmed (%d):\n", len(report.Unconfirmed))
+				for _, i := range report.Unconfirmed {
+					sig := i.Signature
+					if sig == "" {
+						sig = "(no signature)"
+					}
+					fmt.Printf("  %s %-7s %s %s -> %s %s\n",
+						i.ID[:8],
+						i.Status,
+						dix.FmtAmount(i.Amount, i.Token),
+						dix.GetTokenSymbol(i.Token),
+						truncTo(i.To),
+						sig,
+					)
+				}
+			}
+
+			fmt.Println()
+			if !report.Clean() && !accept {
+				fmt.Println("not reconciled, no checkpoint saved (use --accept to save anyway)")
+				return
+			}
+			if err := report.Checkpoint(db); err != nil {
+				die(err)
+			}
+			fmt.Printf("checkpoint saved: %s\n", time.Unix(report.Time, 0).Format("2006-01-02 15:04"))
+		},
+	}
+
+	cmd.Flags().BoolVar(&accept, "accept", false, "save a checkpoint even with differences")
+	cmd.Flags().BoolVar(&history, "history", false, "list past checkpoints")
+
+	return cmd
+}
+
+func reconcileHistory() {
+	db, err := dix.Opendb(dbpath)
+	if err != nil {
+		die(err)
+	}
+	defer db.Close()
+
+	cps, err := dix.ListCheckpoints(db, 50)
+	if err != nil {
+		die(err)
+	}
+
+	if len(cps) == 0 {
+		fmt.Println("no checkpoints")
+		return
+	}
+
+	fmt.Printf("%-16s | %-6s | %16s | %16s\n", "TIME", "TOKEN", "BALANCE", "EXPECTED")
+	fmt.Println(strings.Repeat("-", 64))
+	for _, c := range cps {
+		fmt.Printf("%-16s | %-6s | %16s | %16s\n",
+			time.Unix(c.Time, 0).Format("2006-01-02 15:04"),
+			reconcileSymbol(c.Token),
+			reconcileAmount(int64(c.Balance), c.Token),
+			reconcileAmount(int64(c.Expected), c.Token),
+		)
+	}
+}
+
+func reconcileSymbol(token string) string {
+	if token == "sol" {
+		return "SOL"
+	}
+	return dix.GetTokenSymbol(token)
+}
+
+func reconcileAmount(amt int64, token string) string {
+	sign := ""
+	if amt < 0 {
+		sign = "-"
+		amt = -amt
+	}
+	if token == "sol" {
+		return fmt.Sprintf("%s%.9f", sign, float64(amt)/1e9)
+	}
+	return sign + dix.FmtAmount(uint64(amt), token)
+}
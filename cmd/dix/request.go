@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"dix"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/spf13/cobra"
+)
+
+func requestCmd() *cobra.Command {
+	var memo, label, png string
+
+	cmd := &cobra.Command{
+		Use:   "request <token> <amount>",
+		Short: "create a Solana Pay payment request",
+		Long:  "Example: dix request usdc 25 --label loja --memo \"pedido 42\"",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			token := strings.ToLower(args[0])
+			if _, ok := dix.Tokens[token]; !ok {
+				die(fmt.Errorf("token not supported: %s", token))
+			}
+			amount := parseAmount(args[1], token)
+
+			pubkey := ownPubkey()
+
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			req, err := dix.NewRequest(db, pubkey.String(), token, amount, label, memo)
+			if err != nil {
+				die(err)
+			}
+
+			q, err := qrcode.New(req.URL, qrcode.Medium)
+			if err != nil {
+				die(err)
+			}
+
+			if png != "" {
+				if err := q.WriteFile(512, png); err != nil {
+					die(err)
+				}
+			} else {
+				fmt.Println(q.ToSmallString(false))
+			}
+
+			symbol := dix.GetTokenSymbol(token)
+			fmt.Printf("request: %s\n", req.ID)
+			fmt.Printf("amount: %s %s\n", dix.FmtAmount(amount, token), symbol)
+			fmt.Printf("url: %s\n", req.URL)
+			if png != "" {
+				fmt.Printf("qr: %s\n", png)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&memo, "memo", "", "memo the payer must attach")
+	cmd.Flags().StringVar(&label, "label", "", "label shown by the payer's wallet")
+	cmd.Flags().StringVar(&png, "png", "", "write QR code to PNG file instead of terminal")
+
+	cmd.AddCommand(requestListCmd())
+
+	return cmd
+}
+
+func requestListCmd() *cobra.Command {
+	var status string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list payment requests",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			reqs, err := dix.ListRequests(db, status)
+			if err != nil {
+				die(err)
+			}
+
+			if len(reqs) == 0 {
+				fmt.Println("no requests")
+				return
+			}
+
+			fmt.Printf("%-10s | %14s | %-12s | %-6s | %s\n", "REF", "AMOUNT", "LABEL", "STATUS", "TIME")
+			fmt.Println(strings.Repeat("-", 65))
+
+			now := time.Now().Unix()
+			for _, r := range reqs {
+				fmt.Printf("%-10s | %14s | %-12s | %-6s | %s\n",
+					r.ID[:8],
+					dix.FmtAmount(r.Amount, r.Token)+" "+dix.GetTokenSymbol(r.Token),
+					truncTo(r.Label),
+					r.Status,
+					fmtAgo(now-r.Time),
+				)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&status, "status", "", "filter by status (open, paid)")
+
+	return cmd
+}
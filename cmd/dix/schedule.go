@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"dix"
+
+	"github.com/spf13/cobra"
+)
+
+func scheduleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "recurring payments",
+	}
+
+	cmd.AddCommand(scheduleAddCmd())
+	cmd.AddCommand(scheduleListCmd())
+	cmd.AddCommand(scheduleStatusCmd("pause", "paused", "stop running a schedule"))
+	cmd.AddCommand(scheduleStatusCmd("resume", "active", "resume a paused schedule"))
+	cmd.AddCommand(scheduleRemoveCmd())
+	cmd.AddCommand(scheduleRunCmd())
+
+	return cmd
+}
+
+func scheduleAddCmd() *cobra.Command {
+	var every, cron, memo, start string
+
+	cmd := &cobra.Command{
+		Use:   "add <token> <to> <amount>",
+		Short: "add a recurring payment",
+		Long:  "Examples:\n  dix schedule add usdc joao 100 --cron \"0 9 5 * *\"   # day 5 of every month, 09:00\n  dix schedule add usdt maria 20 --every 7d",
+		Args:  cobra.ExactArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			token := strings.ToLower(args[0])
+			to := args[1]
+			if _, ok := dix.Tokens[token]; !ok {
+				die(fmt.Errorf("token not supported: %s", token))
+			}
+			amount := parseAmount(args[2], token)
+
+			var rule string
+			switch {
+			case every != "" && cron != "":
+				die(fmt.Errorf("use either --every or --cron"))
+			case every != "":
+				rule = "every " + every
+			case cron != "":
+				rule = cron
+			default:
+				die(fmt.Errorf("missing rule: --every or --cron"))
+			}
+
+			from := time.Now()
+			if start != "" {
+				t, err := time.ParseInLocation("2006-01-02 15:04", start, time.Local)
+				if err != nil {
+					die(fmt.Errorf("invalid --start, use \"2006-01-02 15:04\""))
+				}
+				from = t
+			}
+
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			s, err := dix.AddSchedule(db, to, token, amount, memo, rule, from)
+			if err != nil {
+				die(err)
+			}
+
+			fmt.Printf("schedule: %s\n", s.ID)
+			fmt.Printf("%s %s -> %s (%s)\n", dix.FmtAmount(amount, token), dix.GetTokenSymbol(token), to, rule)
+			fmt.Printf("next: %s\n", time.Unix(s.Next, 0).Format("2006-01-02 15:04"))
+		},
+	}
+
+	cmd.Flags().StringVar(&every, "every", "", "interval, e.g. 24h, 7d")
+	cmd.Flags().StringVar(&cron, "cron", "", "cron expression: min hour day month weekday")
+	cmd.Flags().StringVar(&memo, "memo", "", "memo attached to each payment")
+	cmd.Flags().StringVar(&start, "start", "", "first run (\"2006-01-02 15:04\"), default now")
+
+	return cmd
+}
+
+func scheduleListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list schedules",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			schedules, err := dix.ListSchedules(db)
+			if err != nil {
+				die(err)
+			}
+
+			if len(schedules) == 0 {
+				fmt.Println("no schedules")
+				return
+			}
+
+			fmt.Printf("%-8s | %-12s | %14s | %-14s | %-16s | %s\n", "ID", "TO", "AMOUNT", "RULE", "NEXT", "STATUS")
+			fmt.Println(strings.Repeat("-", 90))
+
+			for _, s := range schedules {
+				fmt.Printf("%-8s | %-12s | %14s | %-14s | %-16s | %s\n",
+					s.ID,
+					truncTo(s.To),
+					dix.FmtAmount(s.Amount, s.Token)+" "+dix.GetTokenSymbol(s.Token),
+					s.Rule,
+					time.Unix(s.Next, 0).Format("2006-01-02 15:04"),
+					s.Status,
+				)
+			}
+		},
+	}
+}
+
+func scheduleStatusCmd(use, status, short string) *cobra.Command {
+	return &cobra.Command{
+		Use:   use + " <id>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			if err := dix.SetScheduleStatus(db, args[0], status); err != nil {
+				die(err)
+			}
+
+			fmt.Printf("schedule %s: %s\n", args[0], status)
+		},
+	}
+}
+
+func scheduleRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <id>",
+		Short: "delete a schedule",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			if err := dix.DeleteSchedule(db, args[0]); err != nil {
+				die(fmt.Errorf("schedule not found: %s", args[0]))
+			}
+
+			fmt.Printf("schedule removed: %s\n", args[0])
+		},
+	}
+}
+
+func scheduleRunCmd() *cobra.Command {
+	var catchUp bool
+
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "pay every due schedule (safe to run from cron/systemd)",
+		Long:  "Set DIX_PASSWORD to run without a terminal.\nRerunning never pays the same occurrence twice.",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			schedules, err := dix.ListSchedules(db)
+			if err != nil {
+				die(err)
+			}
+
+			now := time.Now()
+			due := 0
+			for _, s := range schedules {
+				if s.Status == "active" && s.Next <= now.Unix() {
+					due++
+				}
+			}
+			if due == 0 {
+				fmt.Println("nothing due")
+				return
+			}
+			dir := loadContacts()
+
+			pwd := readpwdEnv("password: ")
+			secret, err := dix.Loadwallet(keypath, pwd)
+			if err != nil {
+				die(err)
+			}
+
+			if err := dix.RunSchedules(db, dix.ToSolanaKey(secret), now, catchUp, dix.ResolverFor(db, dir, programID, rpcURL), priceSource(db), rpcURL); err != nil {
+				die(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&catchUp, "catch-up", false, "pay every missed occurrence, not just the latest")
+
+	return cmd
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"dix"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/spf13/cobra"
+)
+
+func serveCmd() *cobra.Command {
+	var addr string
+	var token string
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "run the local HTTP API",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			dir := loadContacts()
+			if token == "" {
+				token = os.Getenv("DIX_API_TOKEN")
+			}
+			if token == "" {
+				b := make([]byte, 24)
+				if _, err := rand.Read(b); err != nil {
+					die(err)
+				}
+				token = hex.EncodeToString(b)
+				fmt.Printf("token: %s\n", token)
+			}
+
+			pwd := readpwdEnv("password: ")
+			secret, err := dix.Loadwallet(keypath, pwd)
+			if err != nil {
+				die(err)
+			}
+
+			keypair := solana.PrivateKey(secret)
+
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			api := &dix.API{
+				DB:      db,
+				Keypair: keypair,
+				Token:   token,
+				Config: dix.Config{
+					RPC:      rpcURL,
+					Keystore: keypath,
+					DbPath:   dbpath,
+					Program:  programID,
+				},
+				Resolver: dix.ResolverFor(db, dir, programID, rpcURL),
+				Prices:   priceSource(db),
+			}
+
+			go func() {
+				for {
+					if _, err := dix.DeliverWebhooks(db); err != nil {
+						fmt.Printf("webhooks: %v\n", err)
+					}
+					time.Sleep(10 * time.Second)
+				}
+			}()
+
+			fmt.Printf("wallet: %s\n", keypair.PublicKey().String())
+			fmt.Printf("rpc: %s\n", rpcURL)
+			fmt.Printf("listening: http://%s\n\n", addr)
+
+			if err := http.ListenAndServe(addr, api.Handler()); err != nil {
+				die(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", "127.0.0.1:8420", "listen address")
+	cmd.Flags().StringVar(&token, "token", "", "bearer token (default $DIX_API_TOKEN or random)")
+
+	return cmd
+}
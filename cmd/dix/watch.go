@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"dix"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/spf13/cobra"
+)
+
+func watchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "watch-only addresses (pubkey only, no keys)",
+	}
+
+	cmd.AddCommand(watchAddCmd())
+	cmd.AddCommand(watchListCmd())
+	cmd.AddCommand(watchRemoveCmd())
+
+	return cmd
+}
+
+func watchAddCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "add <name> <pubkey|username>",
+		Short: "watch an address",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := strings.ToLower(args[0])
+			pubkey := lookupAddress(args[1])
+
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			err = dix.SaveWatch(db, dix.Watch{
+				Name:      name,
+				Pubkey:    pubkey.String(),
+				CreatedAt: time.Now().Unix(),
+			})
+			if err != nil {
+				die(err)
+			}
+
+			fmt.Printf("watching %s: %s\n", name, pubkey.String())
+		},
+	}
+}
+
+func watchListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list your wallet and watched addresses",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			watches, err := dix.ListWatches(db)
+			if err != nil {
+				die(err)
+			}
+
+			fmt.Printf("%-16s | %-5s | %s\n", "NAME", "KIND", "PUBKEY")
+			fmt.Println(strings.Repeat("-", 72))
+
+			if pk, err := dix.Walletpubkey(keypath); err == nil {
+				fmt.Printf("%-16s | %-5s | %s\n", "wallet", "owned", pk.String())
+			}
+			for _, w := range watches {
+				fmt.Printf("%-16s | %-5s | %s\n", w.Name, "watch", w.Pubkey)
+			}
+		},
+	}
+}
+
+func watchRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <name>",
+		Short: "stop watching an address",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			if err := dix.DeleteWatch(db, strings.ToLower(args[0])); err != nil {
+				die(fmt.Errorf("not watching: %s", args[0]))
+			}
+
+			fmt.Printf("removed: %s\n", args[0])
+		},
+	}
+}
+
+func portfolio(fiat string) {
+	type entry struct {
+		name   string
+		pubkey solana.PublicKey
+	}
+
+	var wallets []entry
+	if pk, err := dix.Walletpubkey(keypath); err == nil {
+		wallets = append(wallets, entry{"wallet", pk})
+	}
+
+	db, err := dix.Opendb(dbpath)
+	if err != nil {
+		die(err)
+	}
+	watches, err := dix.ListWatches(db)
+	db.Close()
+	if err != nil {
+		die(err)
+	}
+	for _, w := range watches {
+		pk, err := solana.PublicKeyFromBase58(w.Pubkey)
+		if err != nil {
+			continue
+		}
+		wallets = append(wallets, entry{w.Name, pk})
+	}
+
+	if len(wallets) == 0 {
+		fmt.Println("no wallets (dix init or dix watch add)")
+		return
+	}
+
+	var value fiatTotal
+	if fiat != "" {
+		value = newFiatTotal(fiat)
+		defer value.close()
+	}
+
+	keys := dix.TokenKeys()
+	fmt.Printf("rpc: %s\n\n", rpcURL)
+	fmt.Printf("%-12s", "WALLET")
+	for _, key := range keys {
+		fmt.Printf(" | %14s", dix.GetTokenSymbol(key))
+	}
+	fmt.Printf(" | %12s\n", "SOL")
+	fmt.Println(strings.Repeat("-", 12+len(keys)*17+15))
+
+	totals := make([]uint64, len(keys))
+	var solTotal uint64
+	for _, w := range wallets {
+		fmt.Printf("%-12s", truncTo(w.name))
+		for k, key := range keys {
+			bal, err := dix.Balance(w.pubkey, key, rpcURL)
+			if err != nil {
+				fmt.Printf(" | %14s", "-")
+				continue
+			}
+			totals[k] += bal
+			fmt.Printf(" | %14s", dix.FmtAmount(bal, key))
+		}
+		sol, err := dix.SolBalance(w.pubkey, rpcURL)
+		if err != nil {
+			fmt.Printf(" | %12s\n", "-")
+			continue
+		}
+		solTotal += sol
+		fmt.Printf(" | %12.6f\n", float64(sol)/1e9)
+	}
+
+	fmt.Println(strings.Repeat("-", 12+len(keys)*17+15))
+	fmt.Printf("%-12s", "TOTAL")
+	for k, key := range keys {
+		fmt.Printf(" | %14s", dix.FmtAmount(totals[k], key))
+		value.add(totals[k], key)
+	}
+	fmt.Printf(" | %12.6f\n", float64(solTotal)/1e9)
+	value.add(solTotal, "sol")
+
+	value.print()
+}
+
+func ownPubkey() solana.PublicKey {
+	if pk, err := dix.Walletpubkey(keypath); err == nil {
+		return pk
+	}
+
+	pwd := readpwd("password: ")
+	secret, err := dix.Loadwallet(keypath, pwd)
+	if err != nil {
+		die(err)
+	}
+	fmt.Fprintln(os.Stderr, "hint: run `dix migrate-keystore` to read the balance without a password")
+
+	return solana.PublicKey(secret[32:64])
+}
+
+func lookupAddress(s string) solana.PublicKey {
+	if pk, err := solana.PublicKeyFromBase58(s); err == nil {
+		return pk
+	}
+
+	db, err := dix.Opendb(dbpath)
+	if err != nil {
+		die(err)
+	}
+	defer db.Close()
+
+	if w, err := dix.GetWatch(db, strings.ToLower(s)); err == nil {
+		return solana.MustPublicKeyFromBase58(w.Pubkey)
+	}
+	if !dix.IsName(s) {
+		die(fmt.Errorf("not a pubkey, username, .sol domain or watch name: %s", s))
+	}
+
+	pk, _, err := dix.ResolverFor(db, loadContacts(), programID, rpcURL).Resolve(s, 0, "")
+	if err != nil {
+		die(fmt.Errorf("resolve %s: %w", s, err))
+	}
+	return pk
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"dix"
+
+	"github.com/spf13/cobra"
+)
+
+func webhookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "webhook",
+		Short: "HMAC-signed event notifications",
+	}
+
+	cmd.AddCommand(webhookAddCmd())
+	cmd.AddCommand(webhookListCmd())
+	cmd.AddCommand(webhookRemoveCmd())
+	cmd.AddCommand(webhookDeliverCmd())
+	cmd.AddCommand(webhookOutboxCmd())
+	cmd.AddCommand(webhookRetryCmd())
+
+	return cmd
+}
+
+func webhookAddCmd() *cobra.Command {
+	var events string
+
+	cmd := &cobra.Command{
+		Use:   "add <url>",
+		Short: "register an endpoint",
+		Long:  "Events: " + strings.Join(dix.WebhookEvents, ", ") + "\nUse * for all or a prefix like pool.*",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			var list []string
+			for _, e := range strings.Split(events, ",") {
+				if e = strings.TrimSpace(e); e != "" {
+					list = append(list, e)
+				}
+			}
+
+			w, err := dix.AddWebhook(db, args[0], list)
+			if err != nil {
+				die(err)
+			}
+
+			fmt.Printf("webhook: %s\n", w.ID)
+			fmt.Printf("url: %s\n", w.URL)
+			fmt.Printf("events: %s\n", strings.Join(w.Events, ","))
+			fmt.Printf("secret: %s\n\n", w.Secret)
+			fmt.Println("verify: X-Dix-Signature = hex(hmac_sha256(secret, X-Dix-Timestamp + \".\" + body))")
+		},
+	}
+
+	cmd.Flags().StringVar(&events, "events", "*", "comma-separated events")
+
+	return cmd
+}
+
+func webhookListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list endpoints",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			hooks, err := dix.ListWebhooks(db)
+			if err != nil {
+				die(err)
+			}
+
+			if len(hooks) == 0 {
+				fmt.Println("no webhooks")
+				return
+			}
+
+			fmt.Printf("%-8s | %-40s | %s\n", "ID", "URL", "EVENTS")
+			fmt.Println(strings.Repeat("-", 80))
+
+			for _, w := range hooks {
+				fmt.Printf("%-8s | %-40s | %s\n", w.ID, w.URL, strings.Join(w.Events, ","))
+			}
+		},
+	}
+}
+
+func webhookRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <id>",
+		Short: "delete an endpoint and its pending events",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			if err := dix.DeleteWebhook(db, args[0]); err != nil {
+				die(fmt.Errorf("webhook not found: %s", args[0]))
+			}
+
+			fmt.Printf("webhook removed: %s\n", args[0])
+		},
+	}
+}
+
+func webhookDeliverCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "deliver",
+		Short: "send due events once (safe to run from cron/systemd)",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			n, err := dix.DeliverWebhooks(db)
+			if err != nil {
+				die(err)
+			}
+
+			fmt.Printf("delivered: %d\n", n)
+		},
+	}
+}
+
+func webhookOutboxCmd() *cobra.Command {
+	var status string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "outbox",
+		Short: "show queued and past events",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			events, err := dix.ListOutbox(db, status, limit)
+			if err != nil {
+				die(err)
+			}
+
+			if len(events) == 0 {
+				fmt.Println("outbox empty")
+				return
+			}
+
+			fmt.Printf("%-6s | %-8s | %-16s | %-9s | %-3s | %-10s | %s\n", "ID", "WEBHOOK", "EVENT", "STATUS", "TRY", "CREATED", "ERROR")
+			fmt.Println(strings.Repeat("-", 90))
+
+			for _, e := range events {
+				fmt.Printf("%-6d | %-8s | %-16s | %-9s | %-3d | %-10s | %s\n",
+					e.ID,
+					e.WebhookID,
+					e.Event,
+					e.Status,
+					e.Attempts,
+					fmtAgo(time.Now().Unix()-e.CreatedAt),
+					e.LastError,
+				)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&status, "status", "", "pending, delivered or dead")
+	cmd.Flags().IntVar(&limit, "limit", 20, "max events")
+
+	return cmd
+}
+
+func webhookRetryCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "retry <outbox-id>",
+		Short: "requeue a dead event",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				die(fmt.Errorf("invalid id: %s", args[0]))
+			}
+
+			db, err := dix.Opendb(dbpath)
+			if err != nil {
+				die(err)
+			}
+			defer db.Close()
+
+			if err := dix.RetryOutbox(db, id); err != nil {
+				die(fmt.Errorf("no dead event: %s", args[0]))
+			}
+
+			fmt.Printf("requeued: %d\n", id)
+		},
+	}
+}
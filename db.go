@@ -3,6 +3,8 @@ package dix
 import (
 	"database/sql"
 	"encoding/json"
+	"strings"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -51,44 +53,187 @@ func Opendb(path string) (*sql.DB, error) {
 			member_order INTEGER,
 			PRIMARY KEY (pool_id, username)
 		);
+
+		CREATE TABLE IF NOT EXISTS requests (
+			id TEXT PRIMARY KEY,
+			recipient TEXT,
+			token TEXT,
+			amount INTEGER,
+			label TEXT,
+			message TEXT,
+			memo TEXT,
+			url TEXT,
+			time INTEGER,
+			status TEXT,
+			signature TEXT DEFAULT ''
+		);
+
+		CREATE TABLE IF NOT EXISTS schedules (
+			id TEXT PRIMARY KEY,
+			to_pubkey TEXT,
+			token TEXT,
+			amount INTEGER,
+			memo TEXT,
+			rule TEXT,
+			next_run INTEGER,
+			last_run INTEGER DEFAULT 0,
+			status TEXT,
+			created_at INTEGER
+		);
+
+		CREATE TABLE IF NOT EXISTS cursors (
+			address TEXT PRIMARY KEY,
+			signature TEXT
+		);
+
+		CREATE TABLE IF NOT EXISTS pool_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			pool_id TEXT,
+			kind TEXT,
+			round INTEGER,
+			username TEXT,
+			signature TEXT,
+			intent_id TEXT,
+			time INTEGER
+		);
+
+		CREATE INDEX IF NOT EXISTS pool_events_pool ON pool_events (pool_id, id);
+
+		CREATE TABLE IF NOT EXISTS webhooks (
+			id TEXT PRIMARY KEY,
+			url TEXT,
+			secret TEXT,
+			events TEXT,
+			created_at INTEGER
+		);
+
+		CREATE TABLE IF NOT EXISTS outbox (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			webhook_id TEXT,
+			event TEXT,
+			payload TEXT,
+			attempts INTEGER DEFAULT 0,
+			next_try INTEGER,
+			status TEXT,
+			last_error TEXT DEFAULT '',
+			created_at INTEGER
+		);
+
+		CREATE INDEX IF NOT EXISTS outbox_pending ON outbox (status, next_try);
+
+		CREATE TABLE IF NOT EXISTS prices (
+			token TEXT,
+			fiat TEXT,
+			time INTEGER,
+			price REAL,
+			PRIMARY KEY (token, fiat, time)
+		);
+
+		CREATE TABLE IF NOT EXISTS intent_fiat (
+			intent_id TEXT,
+			fiat TEXT,
+			price REAL,
+			value INTEGER,
+			PRIMARY KEY (intent_id, fiat)
+		);
+
+		CREATE TABLE IF NOT EXISTS watches (
+			name TEXT PRIMARY KEY,
+			pubkey TEXT,
+			created_at INTEGER
+		);
+
+		CREATE TABLE IF NOT EXISTS checkpoints (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			token TEXT,
+			balance INTEGER,
+			expected INTEGER,
+			time INTEGER
+		);
+
+		CREATE TABLE IF NOT EXISTS reverse (
+			pubkey TEXT PRIMARY KEY,
+			username TEXT,
+			fetched_at INTEGER
+		);
 	`)
 	if err != nil {
 		db.Close()
 		return nil, err
 	}
 
+	migrate(db)
+
 	return db, nil
 }
 
-func Save(db *sql.DB, i Intent) error {
-	_, err := db.Exec(`
-		INSERT OR REPLACE INTO intents 
-		(id, from_pubkey, to_pubkey, to_resolved, amount, token, signature, time, status)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, i.ID, i.From, i.To, i.ToResolved, i.Amount, i.Token, i.Signature, i.Time, i.Status)
-	return err
+func migrate(db *sql.DB) {
+	for _, q := range []string{
+		`ALTER TABLE intents ADD COLUMN pool_id TEXT DEFAULT ''`,
+		`ALTER TABLE intents ADD COLUMN round INTEGER DEFAULT 0`,
+		`ALTER TABLE intents ADD COLUMN reference TEXT DEFAULT ''`,
+		`ALTER TABLE intents ADD COLUMN memo TEXT DEFAULT ''`,
+		`ALTER TABLE intents ADD COLUMN direction TEXT DEFAULT 'out'`,
+		`ALTER TABLE intents ADD COLUMN from_alias TEXT DEFAULT ''`,
+		`ALTER TABLE intents ADD COLUMN batch_id TEXT DEFAULT ''`,
+		`ALTER TABLE intents ADD COLUMN schedule_id TEXT DEFAULT ''`,
+		`ALTER TABLE intents ADD COLUMN fee INTEGER DEFAULT 0`,
+		`ALTER TABLE aliases ADD COLUMN fetched_at INTEGER DEFAULT 0`,
+		`ALTER TABLE aliases ADD COLUMN slot INTEGER DEFAULT 0`,
+		`ALTER TABLE aliases ADD COLUMN source TEXT DEFAULT 'registry'`,
+		`ALTER TABLE intents ADD COLUMN to_source TEXT DEFAULT ''`,
+		`CREATE INDEX IF NOT EXISTS intents_signature ON intents (signature)`,
+	} {
+		db.Exec(q)
+	}
 }
 
-func Load(db *sql.DB, id string) (Intent, error) {
+const intentCols = `id, from_pubkey, to_pubkey, to_resolved, amount, token, signature, time, status, pool_id, round, reference, memo, direction, from_alias, batch_id, schedule_id, fee, to_source`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanIntent(row scanner) (Intent, error) {
 	var i Intent
-	var token sql.NullString
-	err := db.QueryRow(`
-		SELECT id, from_pubkey, to_pubkey, to_resolved, amount, token, signature, time, status
-		FROM intents WHERE id = ?
-	`, id).Scan(&i.ID, &i.From, &i.To, &i.ToResolved, &i.Amount, &token, &i.Signature, &i.Time, &i.Status)
+	var token, poolID, reference, memo, direction, fromAlias, batchID, scheduleID, toSource sql.NullString
+	var round, fee sql.NullInt64
+	err := row.Scan(&i.ID, &i.From, &i.To, &i.ToResolved, &i.Amount, &token, &i.Signature, &i.Time, &i.Status, &poolID, &round, &reference, &memo, &direction, &fromAlias, &batchID, &scheduleID, &fee, &toSource)
 	if token.Valid {
 		i.Token = token.String
 	} else {
 		i.Token = "usdc"
 	}
+	i.PoolID = poolID.String
+	i.Round = int(round.Int64)
+	i.Reference = reference.String
+	i.Memo = memo.String
+	i.Direction = direction.String
+	if i.Direction == "" {
+		i.Direction = "out"
+	}
+	i.FromAlias = fromAlias.String
+	i.BatchID = batchID.String
+	i.ScheduleID = scheduleID.String
+	i.Fee = uint64(fee.Int64)
+	i.ToSource = toSource.String
 	return i, err
 }
 
+func Save(db *sql.DB, i Intent) error {
+	_, err := db.Exec(`
+		INSERT OR REPLACE INTO intents (`+intentCols+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, i.ID, i.From, i.To, i.ToResolved, i.Amount, i.Token, i.Signature, i.Time, i.Status, i.PoolID, i.Round, i.Reference, i.Memo, direction(i), i.FromAlias, i.BatchID, i.ScheduleID, i.Fee, i.ToSource)
+	return err
+}
+
+func Load(db *sql.DB, id string) (Intent, error) {
+	return scanIntent(db.QueryRow(`SELECT `+intentCols+` FROM intents WHERE id = ?`, id))
+}
+
 func List(db *sql.DB, limit int) ([]Intent, error) {
-	rows, err := db.Query(`
-		SELECT id, from_pubkey, to_pubkey, to_resolved, amount, token, signature, time, status
-		FROM intents ORDER BY time DESC LIMIT ?
-	`, limit)
+	rows, err := db.Query(`SELECT `+intentCols+` FROM intents ORDER BY time DESC LIMIT ?`, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -96,33 +241,96 @@ func List(db *sql.DB, limit int) ([]Intent, error) {
 
 	var out []Intent
 	for rows.Next() {
-		var i Intent
-		var token sql.NullString
-		err := rows.Scan(&i.ID, &i.From, &i.To, &i.ToResolved, &i.Amount, &token, &i.Signature, &i.Time, &i.Status)
+		i, err := scanIntent(rows)
 		if err != nil {
 			continue
 		}
-		if token.Valid {
-			i.Token = token.String
-		} else {
-			i.Token = "usdc"
-		}
 		out = append(out, i)
 	}
 	return out, rows.Err()
 }
 
+func LastPaid(db *sql.DB, to string) (Intent, error) {
+	return scanIntent(db.QueryRow(`
+		SELECT `+intentCols+` FROM intents
+		WHERE to_pubkey = ? AND direction = 'out' AND status = 'done' AND to_resolved != ''
+		ORDER BY time DESC LIMIT 1
+	`, to))
+}
+
+func direction(i Intent) string {
+	if i.Direction == "" {
+		return "out"
+	}
+	return i.Direction
+}
+
 func Savealias(db *sql.DB, username, pubkey string) error {
-	_, err := db.Exec(`INSERT OR REPLACE INTO aliases (username, pubkey) VALUES (?, ?)`, username, pubkey)
+	return Savealiascache(db, AliasCache{Username: username, Owner: pubkey, FetchedAt: time.Now().Unix(), Source: SourceRegistry})
+}
+
+func Savealiascache(db *sql.DB, c AliasCache) error {
+	_, err := db.Exec(`
+		INSERT OR REPLACE INTO aliases (username, pubkey, fetched_at, slot, source) VALUES (?, ?, ?, ?, ?)
+	`, c.Username, c.Owner, c.FetchedAt, c.Slot, c.Source)
 	return err
 }
 
+func Getaliascache(db *sql.DB, username string) (AliasCache, error) {
+	var c AliasCache
+	var fetchedAt, slot sql.NullInt64
+	var source sql.NullString
+	err := db.QueryRow(`
+		SELECT username, pubkey, fetched_at, slot, source FROM aliases WHERE username = ?
+	`, username).Scan(&c.Username, &c.Owner, &fetchedAt, &slot, &source)
+	c.FetchedAt = fetchedAt.Int64
+	c.Slot = uint64(slot.Int64)
+	c.Source = source.String
+	return c, err
+}
+
 func Getalias(db *sql.DB, username string) (string, error) {
 	var pubkey string
 	err := db.QueryRow(`SELECT pubkey FROM aliases WHERE username = ?`, username).Scan(&pubkey)
 	return pubkey, err
 }
 
+func DeleteAlias(db *sql.DB, username string) error {
+	_, err := db.Exec(`DELETE FROM aliases WHERE username = ?`, username)
+	return err
+}
+
+func SaveReverse(db *sql.DB, pubkey, username string, fetchedAt int64) error {
+	_, err := db.Exec(`
+		INSERT OR REPLACE INTO reverse (pubkey, username, fetched_at) VALUES (?, ?, ?)
+	`, pubkey, username, fetchedAt)
+	return err
+}
+
+func GetReverse(db *sql.DB, pubkey string) (string, int64, error) {
+	var username string
+	var fetchedAt int64
+	err := db.QueryRow(`SELECT username, fetched_at FROM reverse WHERE pubkey = ?`, pubkey).Scan(&username, &fetchedAt)
+	return username, fetchedAt, err
+}
+
+func Aliasof(db *sql.DB, pubkey string) (string, error) {
+	var username string
+	err := db.QueryRow(`SELECT username FROM aliases WHERE pubkey = ? ORDER BY username LIMIT 1`, pubkey).Scan(&username)
+	return username, err
+}
+
+func GetCursor(db *sql.DB, address string) (string, error) {
+	var sig string
+	err := db.QueryRow(`SELECT signature FROM cursors WHERE address = ?`, address).Scan(&sig)
+	return sig, err
+}
+
+func SaveCursor(db *sql.DB, address, sig string) error {
+	_, err := db.Exec(`INSERT OR REPLACE INTO cursors (address, signature) VALUES (?, ?)`, address, sig)
+	return err
+}
+
 func Listaliases(db *sql.DB) ([]Alias, error) {
 	rows, err := db.Query(`SELECT username, pubkey FROM aliases ORDER BY username`)
 	if err != nil {
@@ -139,6 +347,91 @@ func Listaliases(db *sql.DB) ([]Alias, error) {
 	return out, rows.Err()
 }
 
+func SaveRequest(db *sql.DB, r PayRequest) error {
+	_, err := db.Exec(`
+		INSERT OR REPLACE INTO requests
+		(id, recipient, token, amount, label, message, memo, url, time, status, signature)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, r.ID, r.Recipient, r.Token, r.Amount, r.Label, r.Message, r.Memo, r.URL, r.Time, r.Status, r.Signature)
+	return err
+}
+
+func LoadRequest(db *sql.DB, id string) (PayRequest, error) {
+	var r PayRequest
+	err := db.QueryRow(`
+		SELECT id, recipient, token, amount, label, message, memo, url, time, status, signature
+		FROM requests WHERE id = ?
+	`, id).Scan(&r.ID, &r.Recipient, &r.Token, &r.Amount, &r.Label, &r.Message, &r.Memo, &r.URL, &r.Time, &r.Status, &r.Signature)
+	return r, err
+}
+
+func ListRequests(db *sql.DB, status string) ([]PayRequest, error) {
+	rows, err := db.Query(`
+		SELECT id, recipient, token, amount, label, message, memo, url, time, status, signature
+		FROM requests WHERE ? = '' OR status = ? ORDER BY time DESC
+	`, status, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []PayRequest
+	for rows.Next() {
+		var r PayRequest
+		rows.Scan(&r.ID, &r.Recipient, &r.Token, &r.Amount, &r.Label, &r.Message, &r.Memo, &r.URL, &r.Time, &r.Status, &r.Signature)
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func SaveSchedule(db *sql.DB, s Schedule) error {
+	_, err := db.Exec(`
+		INSERT OR REPLACE INTO schedules
+		(id, to_pubkey, token, amount, memo, rule, next_run, last_run, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, s.ID, s.To, s.Token, s.Amount, s.Memo, s.Rule, s.Next, s.LastRun, s.Status, s.CreatedAt)
+	return err
+}
+
+func LoadSchedule(db *sql.DB, id string) (Schedule, error) {
+	var s Schedule
+	err := db.QueryRow(`
+		SELECT id, to_pubkey, token, amount, memo, rule, next_run, last_run, status, created_at
+		FROM schedules WHERE id = ?
+	`, id).Scan(&s.ID, &s.To, &s.Token, &s.Amount, &s.Memo, &s.Rule, &s.Next, &s.LastRun, &s.Status, &s.CreatedAt)
+	return s, err
+}
+
+func ListSchedules(db *sql.DB) ([]Schedule, error) {
+	rows, err := db.Query(`
+		SELECT id, to_pubkey, token, amount, memo, rule, next_run, last_run, status, created_at
+		FROM schedules ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Schedule
+	for rows.Next() {
+		var s Schedule
+		rows.Scan(&s.ID, &s.To, &s.Token, &s.Amount, &s.Memo, &s.Rule, &s.Next, &s.LastRun, &s.Status, &s.CreatedAt)
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func DeleteSchedule(db *sql.DB, id string) error {
+	res, err := db.Exec(`DELETE FROM schedules WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 func SavePool(db *sql.DB, p Pool) error {
 	members, _ := json.Marshal(p.Members)
 	_, err := db.Exec(`
@@ -249,3 +542,229 @@ func ResetPaid(db *sql.DB, poolID string) error {
 	_, err := db.Exec(`UPDATE pool_members SET paid = 0 WHERE pool_id = ?`, poolID)
 	return err
 }
+
+func SavePoolEvent(db *sql.DB, e PoolEvent) error {
+	_, err := db.Exec(`
+		INSERT INTO pool_events (pool_id, kind, round, username, signature, intent_id, time)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, e.PoolID, e.Kind, e.Round, e.Username, e.Signature, e.IntentID, e.Time)
+	if err != nil {
+		return err
+	}
+	return Emit(db, "pool."+e.Kind, e)
+}
+
+func ListPoolEvents(db *sql.DB, poolID string) ([]PoolEvent, error) {
+	rows, err := db.Query(`
+		SELECT id, pool_id, kind, round, username, signature, intent_id, time
+		FROM pool_events WHERE pool_id = ? ORDER BY id
+	`, poolID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []PoolEvent
+	for rows.Next() {
+		var e PoolEvent
+		rows.Scan(&e.ID, &e.PoolID, &e.Kind, &e.Round, &e.Username, &e.Signature, &e.IntentID, &e.Time)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func SaveWebhook(db *sql.DB, w Webhook) error {
+	_, err := db.Exec(`
+		INSERT OR REPLACE INTO webhooks (id, url, secret, events, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, w.ID, w.URL, w.Secret, strings.Join(w.Events, ","), w.CreatedAt)
+	return err
+}
+
+func ListWebhooks(db *sql.DB) ([]Webhook, error) {
+	rows, err := db.Query(`SELECT id, url, secret, events, created_at FROM webhooks ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Webhook
+	for rows.Next() {
+		var w Webhook
+		var events string
+		rows.Scan(&w.ID, &w.URL, &w.Secret, &events, &w.CreatedAt)
+		w.Events = strings.Split(events, ",")
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+func DeleteWebhook(db *sql.DB, id string) error {
+	res, err := db.Exec(`DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	_, err = db.Exec(`DELETE FROM outbox WHERE webhook_id = ? AND status = 'pending'`, id)
+	return err
+}
+
+func SaveOutbox(db *sql.DB, e OutboxEvent) error {
+	if e.ID == 0 {
+		_, err := db.Exec(`
+			INSERT INTO outbox (webhook_id, event, payload, attempts, next_try, status, last_error, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, e.WebhookID, e.Event, e.Payload, e.Attempts, e.NextTry, e.Status, e.LastError, e.CreatedAt)
+		return err
+	}
+	_, err := db.Exec(`
+		UPDATE outbox SET attempts = ?, next_try = ?, status = ?, last_error = ? WHERE id = ?
+	`, e.Attempts, e.NextTry, e.Status, e.LastError, e.ID)
+	return err
+}
+
+func ListOutbox(db *sql.DB, status string, limit int) ([]OutboxEvent, error) {
+	rows, err := db.Query(`
+		SELECT id, webhook_id, event, payload, attempts, next_try, status, last_error, created_at
+		FROM outbox WHERE ? = '' OR status = ? ORDER BY id DESC LIMIT ?
+	`, status, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanOutbox(rows)
+}
+
+func DueOutbox(db *sql.DB, now int64) ([]OutboxEvent, error) {
+	rows, err := db.Query(`
+		SELECT id, webhook_id, event, payload, attempts, next_try, status, last_error, created_at
+		FROM outbox WHERE status = 'pending' AND next_try <= ? ORDER BY id
+	`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanOutbox(rows)
+}
+
+func scanOutbox(rows *sql.Rows) ([]OutboxEvent, error) {
+	var out []OutboxEvent
+	for rows.Next() {
+		var e OutboxEvent
+		rows.Scan(&e.ID, &e.WebhookID, &e.Event, &e.Payload, &e.Attempts, &e.NextTry, &e.Status, &e.LastError, &e.CreatedAt)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func SaveCheckpoint(db *sql.DB, c Checkpoint) error {
+	_, err := db.Exec(`
+		INSERT INTO checkpoints (token, balance, expected, time) VALUES (?, ?, ?, ?)
+	`, c.Token, c.Balance, c.Expected, c.Time)
+	return err
+}
+
+func LastCheckpoint(db *sql.DB, token string) (Checkpoint, error) {
+	var c Checkpoint
+	err := db.QueryRow(`
+		SELECT id, token, balance, expected, time FROM checkpoints
+		WHERE token = ? ORDER BY id DESC LIMIT 1
+	`, token).Scan(&c.ID, &c.Token, &c.Balance, &c.Expected, &c.Time)
+	return c, err
+}
+
+func ListCheckpoints(db *sql.DB, limit int) ([]Checkpoint, error) {
+	rows, err := db.Query(`
+		SELECT id, token, balance, expected, time FROM checkpoints ORDER BY id DESC LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Checkpoint
+	for rows.Next() {
+		var c Checkpoint
+		rows.Scan(&c.ID, &c.Token, &c.Balance, &c.Expected, &c.Time)
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func SavePrice(db *sql.DB, token, fiat string, ts int64, price float64) error {
+	_, err := db.Exec(`INSERT OR REPLACE INTO prices (token, fiat, time, price) VALUES (?, ?, ?, ?)`, token, fiat, ts, price)
+	return err
+}
+
+func SaveFiat(db *sql.DB, f FiatStamp) error {
+	_, err := db.Exec(`
+		INSERT OR REPLACE INTO intent_fiat (intent_id, fiat, price, value) VALUES (?, ?, ?, ?)
+	`, f.IntentID, f.Fiat, f.Price, f.Value)
+	return err
+}
+
+func LoadFiat(db *sql.DB, intentID, fiat string) (FiatStamp, error) {
+	var f FiatStamp
+	err := db.QueryRow(`
+		SELECT intent_id, fiat, price, value FROM intent_fiat WHERE intent_id = ? AND fiat = ?
+	`, intentID, fiat).Scan(&f.IntentID, &f.Fiat, &f.Price, &f.Value)
+	return f, err
+}
+
+func ListFiat(db *sql.DB, intentID string) ([]FiatStamp, error) {
+	rows, err := db.Query(`
+		SELECT intent_id, fiat, price, value FROM intent_fiat WHERE intent_id = ? ORDER BY fiat
+	`, intentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []FiatStamp
+	for rows.Next() {
+		var f FiatStamp
+		rows.Scan(&f.IntentID, &f.Fiat, &f.Price, &f.Value)
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+func SaveWatch(db *sql.DB, w Watch) error {
+	_, err := db.Exec(`INSERT OR REPLACE INTO watches (name, pubkey, created_at) VALUES (?, ?, ?)`, w.Name, w.Pubkey, w.CreatedAt)
+	return err
+}
+
+func GetWatch(db *sql.DB, name string) (Watch, error) {
+	var w Watch
+	err := db.QueryRow(`SELECT name, pubkey, created_at FROM watches WHERE name = ?`, name).Scan(&w.Name, &w.Pubkey, &w.CreatedAt)
+	return w, err
+}
+
+func ListWatches(db *sql.DB) ([]Watch, error) {
+	rows, err := db.Query(`SELECT name, pubkey, created_at FROM watches ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Watch
+	for rows.Next() {
+		var w Watch
+		rows.Scan(&w.Name, &w.Pubkey, &w.CreatedAt)
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+func DeleteWatch(db *sql.DB, name string) error {
+	res, err := db.Exec(`DELETE FROM watches WHERE name = ?`, name)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
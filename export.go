@@ -0,0 +1,235 @@
+package dix
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type exportRow struct {
+	ID          string            `json:"id"`
+	Time        string            `json:"time"`
+	Direction   string            `json:"direction"`
+	Status      string            `json:"status"`
+	Token       string            `json:"token"`
+	Mint        string            `json:"mint"`
+	Amount      string            `json:"amount"`
+	AmountRaw   uint64            `json:"amount_raw"`
+	From        string            `json:"from"`
+	FromAlias   string            `json:"from_alias"`
+	To          string            `json:"to"`
+	ToResolved  string            `json:"to_resolved"`
+	ToSource    string            `json:"to_source"`
+	Signature   string            `json:"signature"`
+	FeeSOL      string            `json:"fee_sol"`
+	FeeLamports uint64            `json:"fee_lamports"`
+	Memo        string            `json:"memo"`
+	Reference   string            `json:"reference"`
+	PoolID      string            `json:"pool_id"`
+	Round       int               `json:"round"`
+	BatchID     string            `json:"batch_id"`
+	ScheduleID  string            `json:"schedule_id"`
+	Fiat        map[string]string `json:"fiat,omitempty"`
+}
+
+var exportHeader = []string{
+	"id", "time", "direction", "status", "token", "mint", "amount", "amount_raw",
+	"from", "from_alias", "to", "to_resolved", "to_source", "signature", "fee_sol", "fee_lamports",
+	"memo", "reference", "pool_id", "round", "batch_id", "schedule_id",
+}
+
+func toExportRow(i Intent, fiat []FiatStamp) exportRow {
+	r := exportRow{
+		ID:          i.ID,
+		Time:        time.Unix(i.Time, 0).UTC().Format(time.RFC3339),
+		Direction:   direction(i),
+		Status:      i.Status,
+		Token:       i.Token,
+		Mint:        GetTokenMint(i.Token),
+		Amount:      FmtAmount(i.Amount, i.Token),
+		AmountRaw:   i.Amount,
+		From:        i.From,
+		FromAlias:   i.FromAlias,
+		To:          i.To,
+		ToResolved:  i.ToResolved,
+		ToSource:    i.ToSource,
+		Signature:   i.Signature,
+		FeeSOL:      FmtAmountDecimals(i.Fee, 9),
+		FeeLamports: i.Fee,
+		Memo:        i.Memo,
+		Reference:   i.Reference,
+		PoolID:      i.PoolID,
+		Round:       i.Round,
+		BatchID:     i.BatchID,
+		ScheduleID:  i.ScheduleID,
+	}
+	for _, f := range fiat {
+		if r.Fiat == nil {
+			r.Fiat = map[string]string{}
+		}
+		r.Fiat[f.Fiat] = fiatDecimal(f.Value)
+	}
+	return r
+}
+
+func ExportCSV(w io.Writer, intents []Intent, fiat map[string][]FiatStamp) error {
+	cw := csv.NewWriter(w)
+	header := append([]string{}, exportHeader...)
+	for _, f := range FiatCurrencies {
+		header = append(header, "value_"+f)
+	}
+	cw.Write(header)
+	for _, i := range intents {
+		r := toExportRow(i, fiat[i.ID])
+		rec := []string{
+			r.ID, r.Time, r.Direction, r.Status, r.Token, r.Mint, r.Amount,
+			strconv.FormatUint(r.AmountRaw, 10),
+			r.From, r.FromAlias, r.To, r.ToResolved, r.ToSource, r.Signature, r.FeeSOL,
+			strconv.FormatUint(r.FeeLamports, 10),
+			r.Memo, r.Reference, r.PoolID, strconv.Itoa(r.Round), r.BatchID, r.ScheduleID,
+		}
+		for _, f := range FiatCurrencies {
+			rec = append(rec, r.Fiat[f])
+		}
+		cw.Write(rec)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func ExportJSON(w io.Writer, intents []Intent, fiat map[string][]FiatStamp) error {
+	rows := []exportRow{}
+	for _, i := range intents {
+		rows = append(rows, toExportRow(i, fiat[i.ID]))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(rows)
+}
+
+var ofxCurrency = map[string]string{
+	"usdc": "USD",
+	"usdt": "USD",
+	"btc":  "XBT",
+	"ltc":  "LTC",
+}
+
+// ExportOFX writes an OFX 2.2 bank statement with one STMTRS per token.
+// Only settled intents are included; network fees are paid in SOL and left out.
+func ExportOFX(w io.Writer, intents []Intent, f LedgerFilter) error {
+	byToken := map[string][]Intent{}
+	account := ""
+	for _, i := range intents {
+		if i.Status != "done" {
+			continue
+		}
+		byToken[i.Token] = append(byToken[i.Token], i)
+		if account == "" {
+			account = i.From
+			if i.Direction == "in" {
+				account = i.To
+			}
+		}
+	}
+
+	now := time.Now().UTC()
+	end := f.Until
+	if end == 0 {
+		end = now.Unix()
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n")
+	b.WriteString(`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n")
+	b.WriteString("<OFX>\n")
+	b.WriteString("<SIGNONMSGSRSV1><SONRS>")
+	b.WriteString("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>")
+	fmt.Fprintf(&b, "<DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE>", ofxTime(now.Unix()))
+	b.WriteString("</SONRS></SIGNONMSGSRSV1>\n")
+	b.WriteString("<BANKMSGSRSV1>\n")
+
+	for n, key := range TokenKeys() {
+		txs := byToken[key]
+		if len(txs) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "<STMTTRNRS><TRNUID>%d</TRNUID>", n+1)
+		b.WriteString("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n")
+		fmt.Fprintf(&b, "<STMTRS><CURDEF>%s</CURDEF>\n", ofxCurrency[key])
+		fmt.Fprintf(&b, "<BANKACCTFROM><BANKID>SOLANA</BANKID><ACCTID>%s</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>\n", ofxEscape(truncRunes(account, 16)+"-"+GetTokenSymbol(key)))
+		start := f.Since
+		if start == 0 {
+			start = txs[0].Time
+		}
+		fmt.Fprintf(&b, "<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>\n", ofxTime(start), ofxTime(end))
+		for _, i := range txs {
+			kind, sign, name := "DEBIT", "-", i.To
+			if i.Direction == "in" {
+				kind, sign, name = "CREDIT", "", i.From
+				if i.FromAlias != "" {
+					name = i.FromAlias
+				}
+			}
+			b.WriteString("<STMTTRN>")
+			fmt.Fprintf(&b, "<TRNTYPE>%s</TRNTYPE>", kind)
+			fmt.Fprintf(&b, "<DTPOSTED>%s</DTPOSTED>", ofxTime(i.Time))
+			fmt.Fprintf(&b, "<TRNAMT>%s%s</TRNAMT>", sign, FmtAmount(i.Amount, key))
+			fmt.Fprintf(&b, "<FITID>%s</FITID>", ofxEscape(i.ID))
+			fmt.Fprintf(&b, "<NAME>%s</NAME>", ofxEscape(truncRunes(name, 32)))
+			if i.Memo != "" {
+				fmt.Fprintf(&b, "<MEMO>%s</MEMO>", ofxEscape(truncRunes(i.Memo, 255)))
+			}
+			b.WriteString("</STMTTRN>\n")
+		}
+		b.WriteString("</BANKTRANLIST>\n")
+		b.WriteString("</STMTRS></STMTTRNRS>\n")
+	}
+
+	b.WriteString("</BANKMSGSRSV1>\n")
+	b.WriteString("</OFX>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func ofxTime(ts int64) string {
+	return time.Unix(ts, 0).UTC().Format("20060102150405") + "[0:GMT]"
+}
+
+func ofxEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func truncRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+func FiatFor(db *sql.DB, intents []Intent) (map[string][]FiatStamp, error) {
+	out := map[string][]FiatStamp{}
+	for _, i := range intents {
+		stamps, err := ListFiat(db, i.ID)
+		if err != nil {
+			return nil, err
+		}
+		out[i.ID] = stamps
+	}
+	return out, nil
+}
+
+func fiatDecimal(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
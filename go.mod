@@ -5,6 +5,7 @@ go 1.24.0
 require (
 	github.com/gagliardetto/solana-go v1.12.0
 	github.com/mr-tron/base58 v1.2.0
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/spf13/cobra v1.10.0
 	github.com/tyler-smith/go-bip39 v1.1.0
 	golang.org/x/term v0.38.0
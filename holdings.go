@@ -0,0 +1,152 @@
+package dix
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+type Holding struct {
+	Account         string
+	Mint            string
+	Token           string
+	Program         string
+	Amount          uint64
+	Decimals        uint8
+	IsATA           bool
+	Frozen          bool
+	Delegate        string
+	DelegatedAmount uint64
+}
+
+func (h Holding) Symbol() string {
+	if h.Token != "" {
+		return GetTokenSymbol(h.Token)
+	}
+	return h.Mint[:4] + "..." + h.Mint[len(h.Mint)-4:]
+}
+
+func (h Holding) Display() string {
+	return FmtAmountDecimals(h.Amount, h.Decimals)
+}
+
+// Holdings lists every token account owned by owner under both the SPL Token
+// and Token-2022 programs. Supported tokens come first in TokenKeys order,
+// then unknown mints by address.
+func Holdings(owner solana.PublicKey, rpcURL string) ([]Holding, error) {
+	client := rpc.New(rpcURL)
+
+	var out []Holding
+	unknown := map[string]bool{}
+	for _, program := range []solana.PublicKey{solana.TokenProgramID, solana.Token2022ProgramID} {
+		res, err := client.GetTokenAccountsByOwner(context.Background(), owner,
+			&rpc.GetTokenAccountsConfig{ProgramId: &program},
+			&rpc.GetTokenAccountsOpts{Encoding: solana.EncodingBase64, Commitment: rpc.CommitmentConfirmed},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("token accounts: %w", err)
+		}
+
+		for _, ta := range res.Value {
+			h, ok := decodeTokenAccount(ta.Account.Data.GetBinary())
+			if !ok {
+				continue
+			}
+			h.Account = ta.Pubkey.String()
+			h.Program = "spl-token"
+			if program.Equals(solana.Token2022ProgramID) {
+				h.Program = "token-2022"
+			}
+
+			mint := solana.MustPublicKeyFromBase58(h.Mint)
+			ata, err := associatedTokenAddress(owner, mint, program)
+			h.IsATA = err == nil && ata.Equals(ta.Pubkey)
+
+			if key, ok := TokenByMint(h.Mint); ok {
+				h.Token = key
+				h.Decimals = GetTokenDecimals(key)
+			} else {
+				unknown[h.Mint] = true
+			}
+			out = append(out, h)
+		}
+	}
+
+	decimals, err := mintDecimals(client, unknown)
+	if err != nil {
+		return nil, err
+	}
+	for k := range out {
+		if out[k].Token == "" {
+			out[k].Decimals = decimals[out[k].Mint]
+		}
+	}
+
+	rank := map[string]int{}
+	for n, key := range TokenKeys() {
+		rank[key] = n
+	}
+	sort.Slice(out, func(a, b int) bool {
+		ha, hb := out[a], out[b]
+		if (ha.Token == "") != (hb.Token == "") {
+			return ha.Token != ""
+		}
+		if ha.Token != hb.Token {
+			return rank[ha.Token] < rank[hb.Token]
+		}
+		if ha.Mint != hb.Mint {
+			return ha.Mint < hb.Mint
+		}
+		if ha.IsATA != hb.IsATA {
+			return ha.IsATA
+		}
+		return ha.Account < hb.Account
+	})
+
+	return out, nil
+}
+
+func decodeTokenAccount(data []byte) (Holding, bool) {
+	if len(data) < 165 {
+		return Holding{}, false
+	}
+	h := Holding{
+		Mint:   solana.PublicKeyFromBytes(data[0:32]).String(),
+		Amount: binary.LittleEndian.Uint64(data[64:72]),
+		Frozen: data[108] == 2,
+	}
+	if binary.LittleEndian.Uint32(data[72:76]) == 1 {
+		h.Delegate = solana.PublicKeyFromBytes(data[76:108]).String()
+		h.DelegatedAmount = binary.LittleEndian.Uint64(data[121:129])
+	}
+	return h, true
+}
+
+func mintDecimals(client *rpc.Client, mints map[string]bool) (map[string]uint8, error) {
+	var keys []solana.PublicKey
+	for m := range mints {
+		keys = append(keys, solana.MustPublicKeyFromBase58(m))
+	}
+
+	out := map[string]uint8{}
+	for start := 0; start < len(keys); start += 100 {
+		end := min(start+100, len(keys))
+		res, err := client.GetMultipleAccounts(context.Background(), keys[start:end]...)
+		if err != nil {
+			return nil, fmt.Errorf("mints: %w", err)
+		}
+		for k, acct := range res.Value {
+			if acct == nil {
+				continue
+			}
+			if data := acct.Data.GetBinary(); len(data) > 44 {
+				out[keys[start+k].String()] = data[44]
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,176 @@
+package dix
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type LedgerFilter struct {
+	Since     int64
+	Until     int64
+	Token     string
+	Status    string
+	Party     string
+	MinAmount uint64
+	MaxAmount uint64
+	Search    string
+}
+
+func (f LedgerFilter) where(db *sql.DB) ([]string, []any) {
+	where := []string{"1 = 1"}
+	var args []any
+	if f.Since > 0 {
+		where = append(where, "time >= ?")
+		args = append(args, f.Since)
+	}
+	if f.Until > 0 {
+		where = append(where, "time < ?")
+		args = append(args, f.Until)
+	}
+	if f.Token != "" {
+		where = append(where, "COALESCE(token, 'usdc') = ?")
+		args = append(args, f.Token)
+	}
+	if f.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, f.Status)
+	}
+	if f.Party != "" {
+		parties := []any{f.Party}
+		if IsName(f.Party) {
+			if pk, err := Getalias(db, strings.ToLower(f.Party)); err == nil {
+				parties = []any{strings.ToLower(f.Party), pk}
+			}
+		}
+		var or []string
+		for _, p := range parties {
+			or = append(or, "to_pubkey = ? OR to_resolved = ? OR (direction = 'in' AND (from_pubkey = ? OR from_alias = ?))")
+			args = append(args, p, p, p, p)
+		}
+		where = append(where, "("+strings.Join(or, " OR ")+")")
+	}
+	if f.MinAmount > 0 {
+		where = append(where, "amount >= ?")
+		args = append(args, f.MinAmount)
+	}
+	if f.MaxAmount > 0 {
+		where = append(where, "amount <= ?")
+		args = append(args, f.MaxAmount)
+	}
+	if f.Search != "" {
+		like := "%" + escapeLike(f.Search) + "%"
+		where = append(where, `(memo LIKE ? ESCAPE '\' OR id LIKE ? ESCAPE '\' OR signature LIKE ? ESCAPE '\' OR reference LIKE ? ESCAPE '\')`)
+		args = append(args, like, like, like, like)
+	}
+	return where, args
+}
+
+func ListFiltered(db *sql.DB, f LedgerFilter) ([]Intent, error) {
+	where, args := f.where(db)
+	rows, err := db.Query(`SELECT `+intentCols+` FROM intents WHERE `+strings.Join(where, " AND ")+` ORDER BY time, id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanIntents(rows)
+}
+
+// Query returns up to limit intents matching f, newest first, and the cursor
+// for the next page ("" when there is none).
+func Query(db *sql.DB, f LedgerFilter, cursor string, limit int) ([]Intent, string, error) {
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("invalid limit: %d", limit)
+	}
+	where, args := f.where(db)
+	if cursor != "" {
+		ts, id, err := parseCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		where = append(where, "(time < ? OR (time = ? AND id < ?))")
+		args = append(args, ts, ts, id)
+	}
+	args = append(args, limit+1)
+
+	rows, err := db.Query(`SELECT `+intentCols+` FROM intents WHERE `+strings.Join(where, " AND ")+` ORDER BY time DESC, id DESC LIMIT ?`, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	out, err := scanIntents(rows)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(out) <= limit {
+		return out, "", nil
+	}
+	out = out[:limit]
+	last := out[len(out)-1]
+	return out, fmt.Sprintf("%d.%s", last.Time, last.ID), nil
+}
+
+func FindIntent(db *sql.DB, prefix string) (Intent, error) {
+	if i, err := Load(db, prefix); err == nil {
+		return i, nil
+	}
+
+	rows, err := db.Query(`SELECT `+intentCols+` FROM intents WHERE id LIKE ? ESCAPE '\' LIMIT 2`, escapeLike(prefix)+"%")
+	if err != nil {
+		return Intent{}, err
+	}
+	defer rows.Close()
+
+	found, err := scanIntents(rows)
+	if err != nil {
+		return Intent{}, err
+	}
+	switch len(found) {
+	case 0:
+		return Intent{}, fmt.Errorf("intent not found: %s", prefix)
+	case 1:
+		return found[0], nil
+	}
+	return Intent{}, fmt.Errorf("ambiguous id %s, type more characters", prefix)
+}
+
+func ExplorerURL(sig, rpcURL string) string {
+	u := "https://explorer.solana.com/tx/" + sig
+	switch {
+	case strings.Contains(rpcURL, "devnet"):
+		u += "?cluster=devnet"
+	case strings.Contains(rpcURL, "testnet"):
+		u += "?cluster=testnet"
+	}
+	return u
+}
+
+func scanIntents(rows *sql.Rows) ([]Intent, error) {
+	var out []Intent
+	for rows.Next() {
+		i, err := scanIntent(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, i)
+	}
+	return out, rows.Err()
+}
+
+func parseCursor(cursor string) (int64, string, error) {
+	ts, id, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return 0, "", fmt.Errorf("invalid cursor: %s", cursor)
+	}
+	t, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid cursor: %s", cursor)
+	}
+	return t, id, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,50 @@
+package dix
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestQueryPaging(t *testing.T) {
+	db, err := Opendb(filepath.Join(t.TempDir(), "dix.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// three intents share each timestamp, so pages split inside a second
+	for n := 0; n < 10; n++ {
+		Save(db, Intent{ID: fmt.Sprintf("i%02d", n), Token: "usdc", Amount: uint64(n + 1), Time: int64(100 + n/3), Status: "done"})
+	}
+	Save(db, Intent{ID: "failed", Token: "usdc", Amount: 1, Time: 200, Status: "fail"})
+
+	var ids []string
+	cursor, pages := "", 0
+	for {
+		page, next, err := Query(db, LedgerFilter{Status: "done"}, cursor, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		for _, i := range page {
+			ids = append(ids, i.ID)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	want := []string{"i09", "i08", "i07", "i06", "i05", "i04", "i03", "i02", "i01", "i00"}
+	if fmt.Sprint(ids) != fmt.Sprint(want) || pages != 3 {
+		t.Fatalf("got %v in %d pages, want %v in 3", ids, pages, want)
+	}
+
+	if _, _, err := Query(db, LedgerFilter{}, "garbage", 4); err == nil {
+		t.Error("bad cursor: want error")
+	}
+	if _, _, err := Query(db, LedgerFilter{}, "", 0); err == nil {
+		t.Error("zero limit: want error")
+	}
+}
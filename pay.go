@@ -6,91 +6,236 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gagliardetto/solana-go"
 )
 
-func Pay(db *sql.DB, keypair solana.PrivateKey, to string, amount uint64, token string, programID, rpcURL string) error {
+// Pay sends amount to a username, .sol domain or pubkey. owner and source
+// are what the caller's Resolve returned for to, after it handled
+// CheckOwnerChange, so the wallet paid is the one the user approved.
+func Pay(db *sql.DB, keypair solana.PrivateKey, to string, owner solana.PublicKey, source string, amount uint64, token, memo string, prices PriceSource, rpcURL string) error {
 	from := keypair.PublicKey()
 	now := time.Now()
 
 	i := Intent{
-		ID:     mkid(from.String(), to, amount, now.Unix()),
-		From:   from.String(),
-		To:     to,
-		Amount: amount,
-		Token:  token,
-		Time:   now.Unix(),
-		Status: "pending",
+		ID:         mkid(from.String(), to, amount, now.Unix()),
+		From:       from.String(),
+		To:         to,
+		ToResolved: owner.String(),
+		ToSource:   source,
+		Amount:     amount,
+		Token:      token,
+		Time:       now.Unix(),
+		Status:     "pending",
+		Memo:       memo,
 	}
 
+	_, err := PayIntent(db, keypair, i, nil, prices, rpcURL)
+	return err
+}
+
+// OwnerChangedError stops a payment to a name that resolves to a different
+// wallet than the last payment to it.
+type OwnerChangedError struct {
+	Name     string
+	Now      solana.PublicKey
+	Last     solana.PublicKey
+	LastTime int64
+}
+
+func (e *OwnerChangedError) Error() string {
+	return fmt.Sprintf("%s now resolves to %s, last paid %s on %s",
+		e.Name, e.Now, e.Last, time.Unix(e.LastTime, 0).Format("2006-01-02"))
+}
+
+// CheckOwnerChange returns an *OwnerChangedError when name was last paid to
+// a wallet other than owner.
+func CheckOwnerChange(db *sql.DB, name string, owner solana.PublicKey) error {
+	last, err := LastPaid(db, name)
+	if err != nil || last.ToResolved == owner.String() {
+		return nil
+	}
+	lastOwner, err := solana.PublicKeyFromBase58(last.ToResolved)
+	if err != nil {
+		return nil
+	}
+	return &OwnerChangedError{Name: name, Now: owner, Last: lastOwner, LastTime: last.Time}
+}
+
+// PayIntent resolves i.To with r unless i.ToResolved is already set or i.To
+// is a pubkey, so r may be nil for those. It
+// fails with an *OwnerChangedError, before signing, when a name it resolves
+// no longer points at the wallet it was last paid to.
+func PayIntent(db *sql.DB, keypair solana.PrivateKey, i Intent, r Resolver, prices PriceSource, rpcURL string) (Intent, error) {
+	from := keypair.PublicKey()
+
 	existing, err := Load(db, i.ID)
 	if err == nil {
 		fmt.Printf("intent %s exists (status: %s)\n", existing.ID[:8], existing.Status)
-		return nil
+		settled, err := Resume(db, existing, prices, rpcURL)
+		if err != nil || settled {
+			existing, _ = Load(db, existing.ID)
+			return existing, err
+		}
+		fmt.Printf("retrying intent %s\n", existing.ID[:8])
+		i.Signature = ""
 	}
 
+	i.Status = "pending"
 	if err := Save(db, i); err != nil {
-		return fmt.Errorf("save: %w", err)
+		return i, fmt.Errorf("save: %w", err)
 	}
 	fmt.Printf("intent: %s\n", i.ID[:8])
 
 	var toPubkey solana.PublicKey
-	if IsUsername(to) {
-		fmt.Printf("resolving %s...\n", to)
-		toPubkey, err = Resolve(db, to, programID, rpcURL)
+	if i.ToResolved != "" {
+		toPubkey, err = solana.PublicKeyFromBase58(i.ToResolved)
 		if err != nil {
 			i.Status = "fail"
-			Save(db, i)
-			return fmt.Errorf("resolve: %w", err)
+			settle(db, i)
+			return i, fmt.Errorf("invalid recipient: %s", i.ToResolved)
 		}
-		fmt.Printf("%s -> %s\n", to, toPubkey.String()[:8]+"...")
-		i.ToResolved = toPubkey.String()
-	} else {
-		toPubkey, err = solana.PublicKeyFromBase58(to)
+	} else if NameSource(i.To) != SourcePubkey {
+		fmt.Printf("resolving %s...\n", i.To)
+		toPubkey, i.ToSource, err = r.Resolve(i.To, i.Amount, i.Token)
 		if err != nil {
 			i.Status = "fail"
-			Save(db, i)
-			return fmt.Errorf("invalid pubkey: %w", err)
+			settle(db, i)
+			return i, fmt.Errorf("resolve: %w", err)
+		}
+		fmt.Printf("%s -> %s\n", i.To, toPubkey.String()[:8]+"...")
+		i.ToResolved = toPubkey.String()
+
+		if err := CheckOwnerChange(db, i.To, toPubkey); err != nil {
+			i.Status = "fail"
+			settle(db, i)
+			return i, err
 		}
-		i.ToResolved = to
+	} else {
+		toPubkey = solana.MustPublicKeyFromBase58(i.To)
+		i.ToResolved = i.To
+		i.ToSource = SourcePubkey
+	}
+
+	if i.Amount == 0 {
+		i.Status = "fail"
+		settle(db, i)
+		return i, fmt.Errorf("amount cannot be zero")
 	}
 
-	if amount == 0 {
+	if err := CheckMemo(i.Memo); err != nil {
 		i.Status = "fail"
-		Save(db, i)
-		return fmt.Errorf("amount cannot be zero")
+		settle(db, i)
+		return i, err
 	}
 
 	start := time.Now()
-	sig, err := Send(from, toPubkey, amount, token, keypair, rpcURL)
+	tx, err := signTransfer(from, toPubkey, i, keypair, rpcURL)
 	if err != nil {
 		i.Status = "fail"
-		Save(db, i)
-		return fmt.Errorf("send: %w", err)
+		settle(db, i)
+		return i, fmt.Errorf("send: %w", err)
+	}
+
+	i.Signature = tx.Signatures[0].String()
+	i.Time = start.Unix()
+	Save(db, i)
+
+	sig, err := submit(tx, rpcURL)
+	if err != nil {
+		i.Status = "fail"
+		settle(db, i)
+		return i, fmt.Errorf("send: %w", err)
 	}
 
-	i.Signature = sig
 	i.Status = "sent"
 	Save(db, i)
 	fmt.Printf("tx: %s\n", sig[:16]+"...")
 
 	if err := Confirm(sig, rpcURL, 30*time.Second); err != nil {
 		i.Status = "fail"
-		Save(db, i)
-		return fmt.Errorf("confirm: %w", err)
+		settle(db, i)
+		return i, fmt.Errorf("confirm: %w", err)
 	}
 
 	elapsed := time.Since(start)
 	i.Status = "done"
-	Save(db, i)
+	i.Fee, _ = txFee(sig, rpcURL)
+	settle(db, i)
+	StampFiat(db, i, prices)
 
-	symbol := GetTokenSymbol(token)
-	decimals := GetTokenDecimals(token)
+	symbol := GetTokenSymbol(i.Token)
+	decimals := GetTokenDecimals(i.Token)
 	fmt.Printf("confirmed (%dms)\n", elapsed.Milliseconds())
-	fmt.Printf("%s %s -> %s\n", fmtAmountDecimals(amount, decimals), symbol, to)
+	fmt.Printf("%s %s -> %s\n", FmtAmountDecimals(i.Amount, decimals), symbol, i.To)
+
+	return i, nil
+}
+
+// settle saves an intent that reached done or fail and queues its webhook.
+// The row is written either way; a failed enqueue is only reported.
+func settle(db *sql.DB, i Intent) {
+	Save(db, i)
+	if err := intentEvent(db, i); err != nil {
+		fmt.Printf("webhook: %v\n", err)
+	}
+}
+
+// Resume settles an intent left behind by an earlier run. It reports
+// settled=false only when the intent provably never reached the chain and
+// is safe to send again.
+func Resume(db *sql.DB, i Intent, prices PriceSource, rpcURL string) (bool, error) {
+	if i.Status == "done" {
+		return true, nil
+	}
+	if i.Signature == "" {
+		return false, nil
+	}
+
+	status, err := sigStatus(i.Signature, rpcURL)
+	if err != nil {
+		return true, fmt.Errorf("status: %w", err)
+	}
+
+	switch status {
+	case "landed":
+		if err := Confirm(i.Signature, rpcURL, 30*time.Second); err != nil {
+			return true, fmt.Errorf("confirm: %w", err)
+		}
+		i.Status = "done"
+		if i.BatchID == "" {
+			i.Fee, _ = txFee(i.Signature, rpcURL)
+		}
+		settle(db, i)
+		StampFiat(db, i, prices)
+		fmt.Printf("intent %s confirmed on chain\n", i.ID[:8])
+		return true, nil
+	case "failed":
+		return false, nil
+	}
+
+	if time.Now().Unix()-i.Time < blockhashTTL {
+		return true, fmt.Errorf("intent %s may still land, retry in %ds", i.ID[:8], blockhashTTL)
+	}
+	return false, nil
+}
 
+const (
+	blockhashTTL = 120
+	maxMemo      = 256
+)
+
+func CheckMemo(memo string) error {
+	if len(memo) > maxMemo {
+		return fmt.Errorf("memo too long: %d bytes (max %d)", len(memo), maxMemo)
+	}
+	if !utf8.ValidString(memo) {
+		return fmt.Errorf("memo must be valid UTF-8")
+	}
 	return nil
 }
 
@@ -99,7 +244,7 @@ func mkid(from, to string, amt uint64, ts int64) string {
 	return hex.EncodeToString(h[:])[:16]
 }
 
-func fmtAmountDecimals(amt uint64, decimals uint8) string {
+func FmtAmountDecimals(amt uint64, decimals uint8) string {
 	divisor := uint64(math.Pow10(int(decimals)))
 	whole := amt / divisor
 	frac := amt % divisor
@@ -111,5 +256,38 @@ func fmtAmountDecimals(amt uint64, decimals uint8) string {
 }
 
 func FmtAmount(amt uint64, token string) string {
-	return fmtAmountDecimals(amt, GetTokenDecimals(token))
+	return FmtAmountDecimals(amt, GetTokenDecimals(token))
+}
+
+func ParseAmount(s string, token string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	decimals := GetTokenDecimals(token)
+	multiplier := uint64(math.Pow10(int(decimals)))
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" {
+		whole = "0"
+	}
+	if len(frac) > int(decimals) {
+		return 0, fmt.Errorf("invalid amount: %s (max %d decimals)", s, decimals)
+	}
+	for len(frac) < int(decimals) {
+		frac += "0"
+	}
+
+	w, err := strconv.ParseUint(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount: %s", s)
+	}
+	var f uint64
+	if frac != "" {
+		f, err = strconv.ParseUint(frac, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount: %s", s)
+		}
+	}
+	if w > (math.MaxUint64-f)/multiplier {
+		return 0, fmt.Errorf("invalid amount: %s (too large)", s)
+	}
+	return w*multiplier + f, nil
 }
@@ -0,0 +1,193 @@
+package dix
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// fakeRPC answers the JSON-RPC calls a token payment makes, so Pay runs
+// against rpcURL without a network. Sent transactions are kept for
+// inspection; accounts answers getMultipleAccounts.
+type fakeRPC struct {
+	mu       sync.Mutex
+	calls    []string
+	sent     []*solana.Transaction
+	blockID  solana.Hash
+	accounts map[solana.PublicKey]fakeAccount
+}
+
+type fakeAccount struct {
+	lamports uint64
+	data     []byte
+}
+
+func (f *fakeRPC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, req.Method)
+
+	ctx := map[string]any{"slot": 1}
+	var result any
+	switch req.Method {
+	case "getLatestBlockhash":
+		result = map[string]any{"context": ctx, "value": map[string]any{
+			"blockhash":            f.blockID.String(),
+			"lastValidBlockHeight": 100,
+		}}
+	case "sendTransaction":
+		var raw string
+		json.Unmarshal(req.Params[0], &raw)
+		tx, err := solana.TransactionFromBase64(raw)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.sent = append(f.sent, tx)
+		result = tx.Signatures[0].String()
+	case "getSignatureStatuses":
+		result = map[string]any{"context": ctx, "value": []any{map[string]any{
+			"slot":               1,
+			"confirmations":      nil,
+			"err":                nil,
+			"confirmationStatus": "confirmed",
+		}}}
+	case "getTransaction":
+		result = nil
+	case "getMultipleAccounts":
+		var keys []solana.PublicKey
+		json.Unmarshal(req.Params[0], &keys)
+		value := []any{}
+		for _, k := range keys {
+			a, ok := f.accounts[k]
+			if !ok {
+				value = append(value, nil)
+				continue
+			}
+			value = append(value, map[string]any{
+				"lamports":   a.lamports,
+				"owner":      solana.SystemProgramID.String(),
+				"data":       []string{base64.StdEncoding.EncodeToString(a.data), "base64"},
+				"executable": false,
+				"rentEpoch":  0,
+			})
+		}
+		result = map[string]any{"context": ctx, "value": value}
+	default:
+		http.Error(w, "unexpected method "+req.Method, http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": result})
+}
+
+func (f *fakeRPC) called(method string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.calls {
+		if c == method {
+			return true
+		}
+	}
+	return false
+}
+
+func payFixture(t *testing.T) (*fakeRPC, string, solana.PrivateKey, *sql.DB) {
+	t.Helper()
+	rpc := &fakeRPC{blockID: solana.Hash(filled(9))}
+	srv := httptest.NewServer(rpc)
+	t.Cleanup(srv.Close)
+
+	db, err := Opendb(filepath.Join(t.TempDir(), "dix.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	keypair, err := solana.NewRandomPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rpc, srv.URL, keypair, db
+}
+
+func TestPayContact(t *testing.T) {
+	rpc, rpcURL, keypair, db := payFixture(t)
+	joao := filled(7)
+	contacts := StaticResolver{"joao": joao}
+
+	owner, source, err := contacts.Resolve("joao", 2_500_000, "usdc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Pay(db, keypair, "joao", owner, source, 2_500_000, "usdc", "", nil, rpcURL); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rpc.sent) != 1 {
+		t.Fatalf("sent %d transactions, want 1", len(rpc.sent))
+	}
+	mint := solana.MustPublicKeyFromBase58(GetTokenMint("usdc"))
+	toATA, _, _ := solana.FindAssociatedTokenAddress(joao, mint)
+	found := false
+	for _, k := range rpc.sent[0].Message.AccountKeys {
+		found = found || k.Equals(toATA)
+	}
+	if !found {
+		t.Errorf("transaction does not credit %s", toATA)
+	}
+
+	intents, err := ListFiltered(db, LedgerFilter{})
+	if err != nil || len(intents) != 1 {
+		t.Fatalf("intents: %v, %v", intents, err)
+	}
+	i := intents[0]
+	if i.Status != "done" || i.ToResolved != joao.String() || i.ToSource != SourceContacts {
+		t.Fatalf("got status=%s to_resolved=%s to_source=%s", i.Status, i.ToResolved, i.ToSource)
+	}
+	if i.Signature != rpc.sent[0].Signatures[0].String() {
+		t.Errorf("signature: got %s", i.Signature)
+	}
+}
+
+func TestPayOwnerChanged(t *testing.T) {
+	rpc, rpcURL, keypair, db := payFixture(t)
+	before, after := filled(7), filled(8)
+
+	Save(db, Intent{ID: "earlier", To: "joao", ToResolved: before.String(), Amount: 1, Token: "usdc", Time: 1, Status: "done"})
+
+	i := Intent{ID: "scheduled", To: "joao", Amount: 1_000_000, Token: "usdc", Time: 2, Status: "pending"}
+	_, err := PayIntent(db, keypair, i, StaticResolver{"joao": after}, nil, rpcURL)
+	var changed *OwnerChangedError
+	if !errors.As(err, &changed) || !changed.Last.Equals(before) || !changed.Now.Equals(after) {
+		t.Fatalf("want OwnerChangedError, got %v", err)
+	}
+	if rpc.called("getLatestBlockhash") || len(rpc.sent) > 0 {
+		t.Fatal("transaction built before the owner change was confirmed")
+	}
+
+	if err := Pay(db, keypair, "joao", after, SourceContacts, 1_000_000, "usdc", "", nil, rpcURL); err != nil {
+		t.Fatal(err)
+	}
+	if len(rpc.sent) != 1 {
+		t.Fatalf("sent %d transactions after confirming, want 1", len(rpc.sent))
+	}
+}
@@ -39,6 +39,11 @@ func CreatePool(db *sql.DB, name, token string, contribution uint64, creator str
 		return Pool{}, err
 	}
 
+	err = poolEvent(db, id, "create", 0, creator, "", "")
+	if err != nil {
+		return Pool{}, err
+	}
+
 	return p, nil
 }
 
@@ -64,7 +69,12 @@ func JoinPool(db *sql.DB, poolID, username, pubkey string) error {
 	}
 
 	order := len(members)
-	return AddPoolMember(db, poolID, username, pubkey, order)
+	err = AddPoolMember(db, poolID, username, pubkey, order)
+	if err != nil {
+		return err
+	}
+
+	return poolEvent(db, poolID, "join", 0, username, "", "")
 }
 
 func StartPool(db *sql.DB, poolID string) error {
@@ -84,10 +94,15 @@ func StartPool(db *sql.DB, poolID string) error {
 
 	p.Status = "active"
 	p.Round = 1
-	return SavePool(db, p)
+	err = SavePool(db, p)
+	if err != nil {
+		return err
+	}
+
+	return poolEvent(db, poolID, "start", p.Round, "", "", "")
 }
 
-func ContributePool(db *sql.DB, poolID, username string, keypair solana.PrivateKey, rpcURL string) error {
+func ContributePool(db *sql.DB, poolID, username string, keypair solana.PrivateKey, prices PriceSource, rpcURL string) error {
 	p, err := LoadPool(db, poolID)
 	if err != nil {
 		return err
@@ -111,25 +126,37 @@ func ContributePool(db *sql.DB, poolID, username string, keypair solana.PrivateK
 		return fmt.Errorf("no winner for round %d", p.Round)
 	}
 
-	winnerPubkey, err := solana.PublicKeyFromBase58(winner.Pubkey)
-	if err != nil {
+	if _, err := solana.PublicKeyFromBase58(winner.Pubkey); err != nil {
 		return err
 	}
 
 	from := keypair.PublicKey()
-	sig, err := Send(from, winnerPubkey, p.Contribution, p.Token, keypair, rpcURL)
+	i := Intent{
+		ID:     mkPoolIntentID(poolID, p.Round, username),
+		From:   from.String(),
+		To:     winner.Pubkey,
+		Amount: p.Contribution,
+		Token:  p.Token,
+		Time:   time.Now().Unix(),
+		Status: "pending",
+		PoolID: poolID,
+		Round:  p.Round,
+	}
+
+	i, err = PayIntent(db, keypair, i, nil, prices, rpcURL)
 	if err != nil {
-		return fmt.Errorf("send: %w", err)
+		return err
+	}
+	if i.Status != "done" {
+		return fmt.Errorf("contribution not settled (status: %s)", i.Status)
 	}
 
-	fmt.Printf("tx: %s\n", sig[:16]+"...")
-
-	err = Confirm(sig, rpcURL, 30*time.Second)
+	err = MarkPaid(db, poolID, username)
 	if err != nil {
 		return err
 	}
 
-	return MarkPaid(db, poolID, username)
+	return poolEvent(db, poolID, "contribute", p.Round, username, i.Signature, i.ID)
 }
 
 func ClaimPool(db *sql.DB, poolID, username string) error {
@@ -169,6 +196,11 @@ func ClaimPool(db *sql.DB, poolID, username string) error {
 		return err
 	}
 
+	err = poolEvent(db, poolID, "claim", p.Round, username, "", "")
+	if err != nil {
+		return err
+	}
+
 	return AdvanceRound(db, poolID)
 }
 
@@ -185,7 +217,11 @@ func AdvanceRound(db *sql.DB, poolID string) error {
 
 	if p.Round >= len(members) {
 		p.Status = "done"
-		return SavePool(db, p)
+		err = SavePool(db, p)
+		if err != nil {
+			return err
+		}
+		return poolEvent(db, poolID, "done", p.Round, "", "", "")
 	}
 
 	p.Round++
@@ -194,7 +230,12 @@ func AdvanceRound(db *sql.DB, poolID string) error {
 		return err
 	}
 
-	return ResetPaid(db, poolID)
+	err = ResetPaid(db, poolID)
+	if err != nil {
+		return err
+	}
+
+	return poolEvent(db, poolID, "advance", p.Round, "", "", "")
 }
 
 func PoolStatus(db *sql.DB, poolID string) (Pool, []PoolMember, error) {
@@ -211,6 +252,37 @@ func PoolStatus(db *sql.DB, poolID string) (Pool, []PoolMember, error) {
 	return p, members, nil
 }
 
+func PoolHistory(db *sql.DB, poolID string) (Pool, []PoolEvent, error) {
+	p, err := LoadPool(db, poolID)
+	if err != nil {
+		return Pool{}, nil, err
+	}
+
+	events, err := ListPoolEvents(db, poolID)
+	if err != nil {
+		return Pool{}, nil, err
+	}
+
+	return p, events, nil
+}
+
+func poolEvent(db *sql.DB, poolID, kind string, round int, username, sig, intentID string) error {
+	return SavePoolEvent(db, PoolEvent{
+		PoolID:    poolID,
+		Kind:      kind,
+		Round:     round,
+		Username:  username,
+		Signature: sig,
+		IntentID:  intentID,
+		Time:      time.Now().Unix(),
+	})
+}
+
+func mkPoolIntentID(poolID string, round int, username string) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("pool:%s:%d:%s", poolID, round, username)))
+	return hex.EncodeToString(h[:])[:16]
+}
+
 func mkPoolID(name, creator string, ts int64) string {
 	h := sha256.Sum256([]byte(fmt.Sprintf("%s:%s:%d", name, creator, ts)))
 	return hex.EncodeToString(h[:])[:12]
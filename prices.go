@@ -0,0 +1,209 @@
+package dix
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type PriceSource interface {
+	Price(token, fiat string, at time.Time) (float64, error)
+}
+
+var FiatCurrencies = []string{"usd", "brl"}
+
+const maxPriceAge = 48 * time.Hour
+
+var ErrNoPrice = errors.New("no price")
+
+type TablePriceSource struct {
+	DB *sql.DB
+}
+
+func (t TablePriceSource) Price(token, fiat string, at time.Time) (float64, error) {
+	var price float64
+	var ts int64
+	err := t.DB.QueryRow(`
+		SELECT price, time FROM prices
+		WHERE token = ? AND fiat = ? AND time <= ?
+		ORDER BY time DESC LIMIT 1
+	`, token, fiat, at.Unix()).Scan(&price, &ts)
+	if err == sql.ErrNoRows || (err == nil && at.Unix()-ts > int64(maxPriceAge.Seconds())) {
+		return 0, fmt.Errorf("%w for %s/%s at %s", ErrNoPrice, token, fiat, at.Format("2006-01-02 15:04"))
+	}
+	return price, err
+}
+
+// HTTPPriceSource asks URL?token=usdc&fiat=brl&time=<unix> and expects
+// {"price": 5.43} back.
+type HTTPPriceSource struct {
+	URL    string
+	Client *http.Client
+}
+
+func (h HTTPPriceSource) Price(token, fiat string, at time.Time) (float64, error) {
+	u, err := url.Parse(h.URL)
+	if err != nil {
+		return 0, err
+	}
+	q := u.Query()
+	q.Set("token", token)
+	q.Set("fiat", fiat)
+	q.Set("time", strconv.FormatInt(at.Unix(), 10))
+	u.RawQuery = q.Encode()
+
+	client := h.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return 0, fmt.Errorf("price: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return 0, fmt.Errorf("%w for %s/%s", ErrNoPrice, token, fiat)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("price: http %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Price *float64 `json:"price"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&body); err != nil {
+		return 0, fmt.Errorf("price: %w", err)
+	}
+	if body.Price == nil || *body.Price <= 0 {
+		return 0, fmt.Errorf("%w for %s/%s", ErrNoPrice, token, fiat)
+	}
+	return *body.Price, nil
+}
+
+type MultiPriceSource []PriceSource
+
+func (m MultiPriceSource) Price(token, fiat string, at time.Time) (float64, error) {
+	err := fmt.Errorf("%w for %s/%s", ErrNoPrice, token, fiat)
+	for _, src := range m {
+		price, e := src.Price(token, fiat, at)
+		if e == nil {
+			return price, nil
+		}
+		err = e
+	}
+	return 0, err
+}
+
+// PriceSourceFor reads the local price table first and then remote, when
+// set, for quotes that were not imported.
+func PriceSourceFor(db *sql.DB, remote PriceSource) PriceSource {
+	if remote == nil {
+		return TablePriceSource{DB: db}
+	}
+	return MultiPriceSource{TablePriceSource{DB: db}, remote}
+}
+
+// ImportPrices loads token,fiat,time,price rows. time is RFC3339, a date
+// (2006-01-02) or unix seconds. Returns the number of rows stored.
+func ImportPrices(db *sql.DB, r io.Reader) (int, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 4
+	cr.TrimLeadingSpace = true
+
+	n := 0
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		if strings.EqualFold(rec[0], "token") {
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+
+		ts, err := parsePriceTime(rec[2])
+		if err != nil {
+			return n, fmt.Errorf("line %d: %w", line, err)
+		}
+		price, err := strconv.ParseFloat(rec[3], 64)
+		if err != nil || price <= 0 {
+			return n, fmt.Errorf("line %d: invalid price %q", line, rec[3])
+		}
+
+		err = SavePrice(db, strings.ToLower(rec[0]), strings.ToLower(rec[1]), ts, price)
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+}
+
+func parsePriceTime(s string) (int64, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.Unix(), nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t.Unix(), nil
+	}
+	if ts, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return ts, nil
+	}
+	return 0, fmt.Errorf("invalid time %q", s)
+}
+
+// FiatValue converts a raw token amount into fiat cents.
+func FiatValue(amount uint64, token string, price float64) int64 {
+	decimals := GetTokenDecimals(token)
+	if token == "sol" {
+		decimals = 9
+	}
+	return int64(math.Round(float64(amount) / math.Pow10(int(decimals)) * price * 100))
+}
+
+func FmtFiat(cents int64, fiat string) string {
+	return fiatDecimal(cents) + " " + strings.ToUpper(fiat)
+}
+
+// StampFiat records the value of a settled intent in every currency of
+// FiatCurrencies, priced at the intent's time. Currencies already stamped
+// are kept. It is called once a payment settles, not from Save, since the
+// price source may be remote. A nil src stamps nothing.
+func StampFiat(db *sql.DB, i Intent, src PriceSource) error {
+	if src == nil {
+		return nil
+	}
+	var errs []error
+	for _, fiat := range FiatCurrencies {
+		if _, err := LoadFiat(db, i.ID, fiat); err == nil {
+			continue
+		}
+		price, err := src.Price(i.Token, fiat, time.Unix(i.Time, 0))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		err = SaveFiat(db, FiatStamp{
+			IntentID: i.ID,
+			Fiat:     fiat,
+			Price:    price,
+			Value:    FiatValue(i.Amount, i.Token, price),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return errors.Join(errs...)
+}
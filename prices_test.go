@@ -0,0 +1,85 @@
+package dix
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPPriceSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch q.Get("fiat") {
+		case "brl":
+			if q.Get("token") != "usdc" || q.Get("time") != "1767225600" || q.Get("key") != "k" {
+				t.Errorf("unexpected query: %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"price": 5.43}`))
+		case "eur":
+			http.NotFound(w, r)
+		case "jpy":
+			w.Write([]byte(`{}`))
+		case "gbp":
+			w.Write([]byte(`not json`))
+		default:
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	src := HTTPPriceSource{URL: srv.URL + "?key=k"}
+	at := time.Unix(1767225600, 0)
+
+	price, err := src.Price("usdc", "brl", at)
+	if err != nil || price != 5.43 {
+		t.Fatalf("brl: got %v, %v", price, err)
+	}
+	for _, fiat := range []string{"eur", "jpy"} {
+		if _, err := src.Price("usdc", fiat, at); !errors.Is(err, ErrNoPrice) {
+			t.Errorf("%s: want ErrNoPrice, got %v", fiat, err)
+		}
+	}
+	for _, fiat := range []string{"gbp", "usd"} {
+		_, err := src.Price("usdc", fiat, at)
+		if err == nil || errors.Is(err, ErrNoPrice) {
+			t.Errorf("%s: want a request error, got %v", fiat, err)
+		}
+	}
+}
+
+func TestSaveDoesNotStamp(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Write([]byte(`{"price": 1}`))
+	}))
+	defer srv.Close()
+
+	db, err := Opendb(filepath.Join(t.TempDir(), "dix.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	i := Intent{ID: "stamp-test", Token: "usdc", Amount: 1_000_000, Time: time.Now().Unix(), Status: "done"}
+	if err := Save(db, i); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFiat(db, i.ID, "usd"); err == nil || hits.Load() != 0 {
+		t.Fatalf("Save stamped the intent (%d price requests)", hits.Load())
+	}
+
+	if err := StampFiat(db, i, PriceSourceFor(db, HTTPPriceSource{URL: srv.URL})); err != nil {
+		t.Fatal(err)
+	}
+	if n := hits.Load(); n != int32(len(FiatCurrencies)) {
+		t.Fatalf("StampFiat made %d price requests, want %d", n, len(FiatCurrencies))
+	}
+	if _, err := LoadFiat(db, i.ID, "usd"); err != nil {
+		t.Fatalf("usd not stamped: %v", err)
+	}
+}
@@ -14,6 +14,10 @@ pub mod dix_registry {
         alias.owner = ctx.accounts.user.key();
         alias.created_at = Clock::get()?.unix_timestamp;
 
+        let reverse = &mut ctx.accounts.reverse;
+        reverse.username = alias.username.clone();
+        reverse.owner = alias.owner;
+
         msg!("Registered: {} -> {}", alias.username, alias.owner);
 
         Ok(())
@@ -26,10 +30,76 @@ pub mod dix_registry {
         let old_owner = alias.owner;
         alias.owner = new_owner;
 
+        let old_reverse = &mut ctx.accounts.old_reverse;
+        if old_reverse.username == alias.username {
+            old_reverse.username = String::new();
+        }
+        old_reverse.owner = old_owner;
+
+        // The new owner did not sign, so only fill a reverse record that is
+        // empty or already names this alias; never replace the name their
+        // wallet shows.
+        let new_reverse = &mut ctx.accounts.new_reverse;
+        if new_reverse.username.is_empty() || new_reverse.username == alias.username {
+            new_reverse.username = alias.username.clone();
+            new_reverse.owner = new_owner;
+        }
+
         msg!("Updated: {} from {} to {}", alias.username, old_owner, new_owner);
 
         Ok(())
     }
+
+    pub fn set_profile(
+        ctx: Context<SetProfile>,
+        display_name: String,
+        url: String,
+        token: Pubkey,
+        memo_required: bool,
+    ) -> Result<()> {
+        let alias = &ctx.accounts.alias;
+        require!(alias.owner == ctx.accounts.user.key(), DixError::NotOwner);
+        require!(display_name.len() <= 32 && url.len() <= 100, DixError::ProfileTooLong);
+
+        let profile = &mut ctx.accounts.profile;
+        profile.owner = alias.owner;
+        profile.display_name = display_name;
+        profile.url = url;
+        profile.token = token;
+        profile.memo_required = memo_required;
+        profile.updated_at = Clock::get()?.unix_timestamp;
+
+        msg!("Profile: {} by {}", alias.username, alias.owner);
+
+        Ok(())
+    }
+
+    pub fn close(ctx: Context<Close>) -> Result<()> {
+        let alias = &ctx.accounts.alias;
+        require!(alias.owner == ctx.accounts.user.key(), DixError::NotOwner);
+
+        // A wallet that only received usernames before reverse records
+        // existed has none; there is nothing to clear then.
+        let info = ctx.accounts.reverse.to_account_info();
+        if info.owner == ctx.program_id && !info.data_is_empty() {
+            let mut reverse = Account::<Reverse>::try_from(&info)?;
+            if reverse.username == alias.username {
+                reverse.username = String::new();
+                reverse.exit(ctx.program_id)?;
+            }
+        }
+
+        // The profile would otherwise outlive the name and hold its rent.
+        let info = ctx.accounts.profile.to_account_info();
+        if info.owner == ctx.program_id && !info.data_is_empty() {
+            let profile = Account::<Profile>::try_from(&info)?;
+            profile.close(ctx.accounts.user.to_account_info())?;
+        }
+
+        msg!("Released: {} by {}", alias.username, alias.owner);
+
+        Ok(())
+    }
 }
 
 #[derive(Accounts)]
@@ -44,6 +114,15 @@ pub struct Register<'info> {
     )]
     pub alias: Account<'info, Alias>,
 
+    #[account(
+        init_if_needed,
+        payer = user,
+        space = 8 + Reverse::INIT_SPACE,
+        seeds = [b"reverse", user.key().as_ref()],
+        bump
+    )]
+    pub reverse: Account<'info, Reverse>,
+
     #[account(mut)]
     pub user: Signer<'info>,
 
@@ -51,10 +130,78 @@ pub struct Register<'info> {
 }
 
 #[derive(Accounts)]
+#[instruction(new_owner: Pubkey)]
 pub struct Update<'info> {
     #[account(mut)]
     pub alias: Account<'info, Alias>,
 
+    #[account(
+        init_if_needed,
+        payer = user,
+        space = 8 + Reverse::INIT_SPACE,
+        seeds = [b"reverse", user.key().as_ref()],
+        bump
+    )]
+    pub old_reverse: Account<'info, Reverse>,
+
+    #[account(
+        init_if_needed,
+        payer = user,
+        space = 8 + Reverse::INIT_SPACE,
+        seeds = [b"reverse", new_owner.as_ref()],
+        bump
+    )]
+    pub new_reverse: Account<'info, Reverse>,
+
+    #[account(mut)]
+    pub user: Signer<'info>,
+
+    pub system_program: Program<'info, System>,
+}
+
+#[derive(Accounts)]
+pub struct SetProfile<'info> {
+    pub alias: Account<'info, Alias>,
+
+    #[account(
+        init_if_needed,
+        payer = user,
+        space = 8 + Profile::INIT_SPACE,
+        seeds = [b"profile", alias.username.as_bytes()],
+        bump
+    )]
+    pub profile: Account<'info, Profile>,
+
+    #[account(mut)]
+    pub user: Signer<'info>,
+
+    pub system_program: Program<'info, System>,
+}
+
+#[derive(Accounts)]
+pub struct Close<'info> {
+    #[account(mut, close = user)]
+    pub alias: Account<'info, Alias>,
+
+    /// CHECK: the user's reverse record, which may not exist; decoded in
+    /// the handler only when it does.
+    #[account(
+        mut,
+        seeds = [b"reverse", user.key().as_ref()],
+        bump
+    )]
+    pub reverse: UncheckedAccount<'info>,
+
+    /// CHECK: the alias profile, which may not exist; closed in the handler
+    /// when it does.
+    #[account(
+        mut,
+        seeds = [b"profile", alias.username.as_bytes()],
+        bump
+    )]
+    pub profile: UncheckedAccount<'info>,
+
+    #[account(mut)]
     pub user: Signer<'info>,
 }
 
@@ -67,10 +214,33 @@ pub struct Alias {
     pub created_at: i64,
 }
 
+#[account]
+#[derive(InitSpace)]
+pub struct Reverse {
+    #[max_len(20)]
+    pub username: String,
+    pub owner: Pubkey,
+}
+
+#[account]
+#[derive(InitSpace)]
+pub struct Profile {
+    pub owner: Pubkey,
+    #[max_len(32)]
+    pub display_name: String,
+    #[max_len(100)]
+    pub url: String,
+    pub token: Pubkey,
+    pub memo_required: bool,
+    pub updated_at: i64,
+}
+
 #[error_code]
 pub enum DixError {
     #[msg("Username must be 3-20 characters")]
     InvalidUsername,
     #[msg("Not the owner of this alias")]
     NotOwner,
+    #[msg("Display name max 32 and URL max 100 characters")]
+    ProfileTooLong,
 }
@@ -0,0 +1,282 @@
+package dix
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+var memoV1ProgramID = solana.MustPublicKeyFromBase58("Memo1UhkJRfHyvLMcVucJwxXeuD728EqVDDwQDxFMNo")
+
+func Receive(db *sql.DB, owner solana.PublicKey, prices PriceSource, programID, rpcURL string) ([]Intent, error) {
+	client := rpc.New(rpcURL)
+
+	var got []Intent
+	for _, key := range TokenKeys() {
+		mint := solana.MustPublicKeyFromBase58(GetTokenMint(key))
+		ata, _, err := solana.FindAssociatedTokenAddress(owner, mint)
+		if err != nil {
+			return got, err
+		}
+
+		var until solana.Signature
+		if cursor, _ := GetCursor(db, ata.String()); cursor != "" {
+			until = solana.MustSignatureFromBase58(cursor)
+		}
+
+		sigs, err := walkSignatures(client, ata, until, 0)
+		if err != nil {
+			return got, fmt.Errorf("signatures %s: %w", GetTokenSymbol(key), err)
+		}
+
+		for k := len(sigs) - 1; k >= 0; k-- {
+			s := sigs[k]
+			if s.Err == nil {
+				transfers, err := TxTransfers(owner, s.Signature, rpcURL)
+				if err != nil {
+					return got, fmt.Errorf("tx %s: %w", s.Signature.String()[:16], err)
+				}
+				for _, t := range transfers {
+					if t.Direction != "in" || t.Account != ata.String() {
+						continue
+					}
+					i, isnew, err := recordIncoming(db, t, programID, rpcURL)
+					if err != nil {
+						return got, err
+					}
+					if isnew {
+						if err := intentEvent(db, i); err != nil {
+							fmt.Printf("webhook: %v\n", err)
+						}
+						StampFiat(db, i, prices)
+						got = append(got, i)
+					}
+				}
+			}
+			SaveCursor(db, ata.String(), s.Signature.String())
+		}
+	}
+
+	return got, nil
+}
+
+func TxTransfers(owner solana.PublicKey, sig solana.Signature, rpcURL string) ([]Transfer, error) {
+	client := rpc.New(rpcURL)
+	version := uint64(0)
+
+	out, err := client.GetTransaction(context.Background(), sig, &rpc.GetTransactionOpts{
+		Encoding:                       solana.EncodingBase64,
+		Commitment:                     rpc.CommitmentConfirmed,
+		MaxSupportedTransactionVersion: &version,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeTransfers(owner, sig.String(), out)
+}
+
+func decodeTransfers(owner solana.PublicKey, sig string, out *rpc.GetTransactionResult) ([]Transfer, error) {
+	if out == nil || out.Meta == nil || out.Transaction == nil {
+		return nil, fmt.Errorf("transaction not available")
+	}
+	if out.Meta.Err != nil {
+		return nil, nil
+	}
+
+	tx, err := out.Transaction.GetTransaction()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := append(solana.PublicKeySlice{}, tx.Message.AccountKeys...)
+	keys = append(keys, out.Meta.LoadedAddresses.Writable...)
+	keys = append(keys, out.Meta.LoadedAddresses.ReadOnly...)
+
+	accounts := make([]string, len(keys))
+	for k, key := range keys {
+		accounts[k] = key.String()
+	}
+
+	var memos []string
+	for _, ix := range tx.Message.Instructions {
+		if int(ix.ProgramIDIndex) >= len(keys) {
+			continue
+		}
+		program := keys[ix.ProgramIDIndex]
+		if program.Equals(solana.MemoProgramID) || program.Equals(memoV1ProgramID) {
+			memos = append(memos, string(ix.Data))
+		}
+	}
+
+	type balance struct {
+		mint      string
+		owner     string
+		pre, post uint64
+	}
+	balances := map[uint16]*balance{}
+	var order []uint16
+	get := func(tb rpc.TokenBalance) *balance {
+		b, ok := balances[tb.AccountIndex]
+		if !ok {
+			b = &balance{mint: tb.Mint.String()}
+			if tb.Owner != nil {
+				b.owner = tb.Owner.String()
+			}
+			balances[tb.AccountIndex] = b
+			order = append(order, tb.AccountIndex)
+		}
+		return b
+	}
+	for _, tb := range out.Meta.PreTokenBalances {
+		get(tb).pre = rawAmount(tb)
+	}
+	for _, tb := range out.Meta.PostTokenBalances {
+		get(tb).post = rawAmount(tb)
+	}
+	slices.Sort(order)
+
+	var blockTime int64
+	if out.BlockTime != nil {
+		blockTime = int64(*out.BlockTime)
+	}
+
+	var transfers []Transfer
+	for _, idx := range order {
+		b := balances[idx]
+		if b.owner != owner.String() || b.pre == b.post || int(idx) >= len(keys) {
+			continue
+		}
+
+		t := Transfer{
+			Signature: sig,
+			Account:   accounts[idx],
+			Owner:     b.owner,
+			Mint:      b.mint,
+			Memo:      strings.Join(memos, "; "),
+			Accounts:  accounts,
+			Fee:       out.Meta.Fee,
+			Slot:      out.Slot,
+			Time:      blockTime,
+		}
+		t.Token, _ = TokenByMint(b.mint)
+
+		var best uint64
+		for _, other := range order {
+			o := balances[other]
+			if other == idx || o.mint != b.mint || o.owner == b.owner {
+				continue
+			}
+			var moved uint64
+			if b.post > b.pre && o.pre > o.post {
+				moved = o.pre - o.post
+			}
+			if b.pre > b.post && o.post > o.pre {
+				moved = o.post - o.pre
+			}
+			if moved > best {
+				best = moved
+				t.Counterparty = o.owner
+			}
+		}
+
+		if b.post > b.pre {
+			t.Direction = "in"
+			t.Amount = b.post - b.pre
+		} else {
+			t.Direction = "out"
+			t.Amount = b.pre - b.post
+		}
+		transfers = append(transfers, t)
+	}
+
+	return transfers, nil
+}
+
+// recordIncoming stores an incoming transfer not seen before. It queues no
+// webhook: ledger sync backfills history through it, and only Receive
+// reports a payment as it arrives.
+func recordIncoming(db *sql.DB, t Transfer, programID, rpcURL string) (Intent, bool, error) {
+	if t.Token == "" {
+		return Intent{}, false, nil
+	}
+
+	id := chainID(t.Signature, t.Account)
+	if _, err := Load(db, id); err == nil {
+		return Intent{}, false, nil
+	}
+
+	i := Intent{
+		ID:         id,
+		From:       t.Counterparty,
+		To:         t.Owner,
+		ToResolved: t.Owner,
+		Amount:     t.Amount,
+		Token:      t.Token,
+		Signature:  t.Signature,
+		Time:       t.Time,
+		Status:     "done",
+		Memo:       t.Memo,
+		Direction:  "in",
+	}
+	if t.Counterparty != "" {
+		i.FromAlias, _ = ReverseResolve(db, t.Counterparty, programID, rpcURL)
+	}
+
+	if r, ok := matchRequest(db, t); ok {
+		i.Reference = r.ID
+		r.Signature = t.Signature
+		r.Status = "paid"
+		if t.Amount < r.Amount {
+			r.Status = "underpaid"
+		}
+		if err := SaveRequest(db, r); err != nil {
+			return Intent{}, false, err
+		}
+	}
+
+	if err := Save(db, i); err != nil {
+		return Intent{}, false, err
+	}
+	return i, true, nil
+}
+
+func matchRequest(db *sql.DB, t Transfer) (PayRequest, bool) {
+	open, err := ListRequests(db, "open")
+	if err != nil {
+		return PayRequest{}, false
+	}
+
+	for _, r := range open {
+		if r.Recipient == t.Owner && r.Token == t.Token && slices.Contains(t.Accounts, r.ID) {
+			return r, true
+		}
+	}
+	for _, r := range open {
+		if r.Recipient == t.Owner && r.Token == t.Token && r.Memo != "" && r.Memo == t.Memo {
+			return r, true
+		}
+	}
+	return PayRequest{}, false
+}
+
+func rawAmount(tb rpc.TokenBalance) uint64 {
+	if tb.UiTokenAmount == nil {
+		return 0
+	}
+	n, _ := strconv.ParseUint(tb.UiTokenAmount.Amount, 10, 64)
+	return n
+}
+
+func chainID(sig, account string) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("chain:%s:%s", sig, account)))
+	return hex.EncodeToString(h[:])[:16]
+}
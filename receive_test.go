@@ -0,0 +1,106 @@
+package dix
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func txResult(t *testing.T, tx *solana.Transaction, fail bool, balances string) *rpc.GetTransactionResult {
+	t.Helper()
+	raw, err := tx.ToBase64()
+	if err != nil {
+		t.Fatal(err)
+	}
+	txErr := "null"
+	if fail {
+		txErr = `{"InstructionError":[0,"Custom"]}`
+	}
+	var out rpc.GetTransactionResult
+	err = json.Unmarshal([]byte(fmt.Sprintf(`{
+		"slot": 42,
+		"blockTime": 1767225600,
+		"transaction": [%q, "base64"],
+		"meta": {"err": %s, "fee": 5000, %s,
+			"loadedAddresses": {"writable": [], "readonly": []}}
+	}`, raw, txErr, balances)), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &out
+}
+
+func TestDecodeTransfers(t *testing.T) {
+	payer, me := filled(1), filled(2)
+	mint := solana.MustPublicKeyFromBase58(GetTokenMint("usdc"))
+	fromATA, _, _ := solana.FindAssociatedTokenAddress(payer, mint)
+	toATA, _, _ := solana.FindAssociatedTokenAddress(me, mint)
+
+	tx, err := solana.NewTransaction([]solana.Instruction{
+		solana.NewInstruction(solana.TokenProgramID, solana.AccountMetaSlice{
+			solana.Meta(fromATA).WRITE(), solana.Meta(toATA).WRITE(), solana.Meta(payer).SIGNER(),
+		}, []byte{3}),
+		solana.NewInstruction(solana.MemoProgramID, nil, []byte("fatura 7")),
+	}, solana.Hash(filled(9)), solana.TransactionPayer(payer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := func(k solana.PublicKey) int {
+		for n, key := range tx.Message.AccountKeys {
+			if key.Equals(k) {
+				return n
+			}
+		}
+		return -1
+	}
+	balance := func(acct, owner solana.PublicKey, amount int) string {
+		return fmt.Sprintf(`{"accountIndex": %d, "mint": %q, "owner": %q, "uiTokenAmount": {"amount": "%d", "decimals": 6}}`,
+			index(acct), mint, owner, amount)
+	}
+	balances := fmt.Sprintf(`"preTokenBalances": [%s, %s], "postTokenBalances": [%s, %s]`,
+		balance(fromATA, payer, 9_000_000), balance(toATA, me, 1_000_000),
+		balance(fromATA, payer, 6_500_000), balance(toATA, me, 3_500_000))
+
+	got, err := decodeTransfers(me, "sig", txResult(t, tx, false, balances))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d transfers, want 1", len(got))
+	}
+	tr := got[0]
+	if tr.Direction != "in" || tr.Amount != 2_500_000 || tr.Token != "usdc" || tr.Account != toATA.String() ||
+		tr.Counterparty != payer.String() || tr.Memo != "fatura 7" || tr.Time != 1767225600 || tr.Slot != 42 || tr.Fee != 5000 {
+		t.Errorf("got %+v", tr)
+	}
+
+	sent, _ := decodeTransfers(payer, "sig", txResult(t, tx, false, balances))
+	if len(sent) != 1 || sent[0].Direction != "out" || sent[0].Amount != 2_500_000 || sent[0].Counterparty != me.String() {
+		t.Errorf("sender view: %+v", sent)
+	}
+
+	if failed, err := decodeTransfers(me, "sig", txResult(t, tx, true, balances)); err != nil || len(failed) != 0 {
+		t.Errorf("failed tx: %v, %v", failed, err)
+	}
+}
+
+func TestRecordIncomingQueuesNothing(t *testing.T) {
+	_, rpcURL, _, db := payFixture(t)
+	if _, err := AddWebhook(db, "https://example.com/hook", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tr := Transfer{Signature: "sig", Account: filled(3).String(), Owner: filled(2).String(), Token: "usdc", Amount: 1, Direction: "in", Time: 1}
+	if _, added, err := recordIncoming(db, tr, "", rpcURL); err != nil || !added {
+		t.Fatalf("added=%v, %v", added, err)
+	}
+	if _, added, _ := recordIncoming(db, tr, "", rpcURL); added {
+		t.Error("same transfer recorded twice")
+	}
+	if events, _ := ListOutbox(db, "", 10); len(events) != 0 {
+		t.Fatalf("backfill queued %d events", len(events))
+	}
+}
@@ -0,0 +1,160 @@
+package dix
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+type ReconcileReport struct {
+	Time        int64
+	Rows        []Reconciliation
+	Unmatched   []Transfer
+	Unconfirmed []Intent
+}
+
+func (r Reconciliation) Diff() int64 {
+	return int64(r.Actual) - r.Expected
+}
+
+func (r ReconcileReport) Clean() bool {
+	for _, row := range r.Rows {
+		if row.Token != "sol" && (row.Err != nil || row.Diff() != 0) {
+			return false
+		}
+	}
+	return len(r.Unmatched) == 0 && len(r.Unconfirmed) == 0
+}
+
+// Reconcile compares the balance each token should have according to the
+// ledger (last checkpoint + in - out) with the on-chain balance. The SOL row
+// only accounts for transaction fees recorded on intents, so rent and
+// registration costs show up as a difference there.
+func Reconcile(db *sql.DB, owner solana.PublicKey, rpcURL string) (ReconcileReport, error) {
+	client := rpc.New(rpcURL)
+	report := ReconcileReport{Time: time.Now().Unix()}
+
+	for _, key := range append(TokenKeys(), "sol") {
+		row := Reconciliation{Token: key}
+		if cp, err := LastCheckpoint(db, key); err == nil {
+			row.Since = cp.Time
+			row.Checkpoint = cp.Balance
+		}
+
+		f := LedgerFilter{Status: "done"}
+		if row.Since > 0 {
+			f.Since = row.Since + 1
+		}
+		if key != "sol" {
+			f.Token = key
+		}
+		intents, err := ListFiltered(db, f)
+		if err != nil {
+			return report, err
+		}
+
+		for _, i := range intents {
+			if key == "sol" {
+				if direction(i) == "out" {
+					row.Fees += i.Fee
+				}
+				continue
+			}
+			if i.Direction == "in" {
+				row.In += i.Amount
+			} else {
+				row.Out += i.Amount
+			}
+		}
+		row.Expected = int64(row.Checkpoint) + int64(row.In) - int64(row.Out) - int64(row.Fees)
+
+		if key == "sol" {
+			row.Actual, row.Err = SolBalance(owner, rpcURL)
+		} else {
+			row.Actual, row.Err = Balance(owner, key, rpcURL)
+			if row.Err != nil && row.Expected == 0 {
+				row.Err = nil
+			}
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	for _, key := range TokenKeys() {
+		mint := solana.MustPublicKeyFromBase58(GetTokenMint(key))
+		ata, _, err := solana.FindAssociatedTokenAddress(owner, mint)
+		if err != nil {
+			return report, err
+		}
+
+		var since int64
+		if cp, err := LastCheckpoint(db, key); err == nil {
+			since = cp.Time
+		}
+
+		sigs, err := walkSignatures(client, ata, solana.Signature{}, since)
+		if err != nil {
+			return report, fmt.Errorf("signatures %s: %w", GetTokenSymbol(key), err)
+		}
+
+		for _, s := range sigs {
+			if s.Err != nil {
+				continue
+			}
+			local, err := intentsBySignature(db, s.Signature.String())
+			if err != nil {
+				return report, err
+			}
+			if len(local) > 0 {
+				continue
+			}
+
+			transfers, err := TxTransfers(owner, s.Signature, rpcURL)
+			if err != nil {
+				return report, fmt.Errorf("tx %s: %w", s.Signature.String()[:16], err)
+			}
+			for _, t := range transfers {
+				if t.Account == ata.String() {
+					report.Unmatched = append(report.Unmatched, t)
+				}
+			}
+		}
+	}
+
+	// A fail with a signature may still have landed (e.g. the confirmation
+	// timed out), so it is listed until resumed or resolved.
+	rows, err := db.Query(`SELECT ` + intentCols + ` FROM intents WHERE status IN ('pending', 'sent') OR (status = 'fail' AND COALESCE(signature, '') != '') ORDER BY time`)
+	if err != nil {
+		return report, err
+	}
+	defer rows.Close()
+	report.Unconfirmed, err = scanIntents(rows)
+
+	return report, err
+}
+
+// Checkpoint stores the on-chain balances of the report as the new baseline,
+// accepting any difference found.
+func (r ReconcileReport) Checkpoint(db *sql.DB) error {
+	for _, row := range r.Rows {
+		if row.Err != nil {
+			continue
+		}
+		expected := uint64(0)
+		if row.Expected > 0 {
+			expected = uint64(row.Expected)
+		}
+		err := SaveCheckpoint(db, Checkpoint{
+			Token:    row.Token,
+			Balance:  row.Actual,
+			Expected: expected,
+			Time:     r.Time,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,425 @@
+package dix
+
+import (
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// The registry client is driven by the Anchor IDL of program/src/lib.rs:
+// account order, signer/writable flags and argument types all come from it.
+// Regenerate with `anchor build` and copy target/idl/dix_registry.json here
+// whenever the program changes.
+//
+//go:embed program/idl/dix_registry.json
+var registryIDLJSON []byte
+
+type idl struct {
+	Name         string           `json:"name"`
+	Instructions []idlInstruction `json:"instructions"`
+	Accounts     []idlAccount     `json:"accounts"`
+	Errors       []idlError       `json:"errors"`
+}
+
+type idlInstruction struct {
+	Name     string `json:"name"`
+	Accounts []struct {
+		Name     string `json:"name"`
+		IsMut    bool   `json:"isMut"`
+		IsSigner bool   `json:"isSigner"`
+	} `json:"accounts"`
+	Args []idlField `json:"args"`
+}
+
+type idlAccount struct {
+	Name string `json:"name"`
+	Type struct {
+		Fields []idlField `json:"fields"`
+	} `json:"type"`
+}
+
+type idlField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type idlError struct {
+	Code int    `json:"code"`
+	Name string `json:"name"`
+	Msg  string `json:"msg"`
+}
+
+var registryIDL = func() idl {
+	var v idl
+	if err := json.Unmarshal(registryIDLJSON, &v); err != nil {
+		panic("registry idl: " + err.Error())
+	}
+	return v
+}()
+
+// AliasAccount is the on-chain Alias account of the registry.
+type AliasAccount struct {
+	Username  string
+	Owner     solana.PublicKey
+	CreatedAt int64
+}
+
+// ReverseAccount is the registry's reverse record: the username a wallet
+// registered last, or received last by transfer.
+type ReverseAccount struct {
+	Username string
+	Owner    solana.PublicKey
+}
+
+// ProfileAccount is the optional metadata of an alias. It is kept across
+// transfers, so it only counts while Owner matches the alias owner.
+type ProfileAccount struct {
+	Owner        solana.PublicKey
+	DisplayName  string
+	URL          string
+	Token        solana.PublicKey
+	MemoRequired bool
+	UpdatedAt    int64
+}
+
+// instructionDiscriminator takes the IDL (camelCase) name; Anchor hashes the
+// snake_case name of the Rust function.
+func instructionDiscriminator(name string) []byte {
+	var snake strings.Builder
+	for _, c := range name {
+		if c >= 'A' && c <= 'Z' {
+			snake.WriteByte('_')
+			c += 'a' - 'A'
+		}
+		snake.WriteRune(c)
+	}
+	h := sha256.Sum256([]byte("global:" + snake.String()))
+	return h[:8]
+}
+
+func accountDiscriminator(name string) []byte {
+	h := sha256.Sum256([]byte("account:" + name))
+	return h[:8]
+}
+
+func AliasPDA(program solana.PublicKey, username string) (solana.PublicKey, error) {
+	pda, _, err := solana.FindProgramAddress(
+		[][]byte{[]byte("alias"), []byte(username)},
+		program,
+	)
+	return pda, err
+}
+
+func ReversePDA(program, owner solana.PublicKey) (solana.PublicKey, error) {
+	pda, _, err := solana.FindProgramAddress(
+		[][]byte{[]byte("reverse"), owner[:]},
+		program,
+	)
+	return pda, err
+}
+
+func ProfilePDA(program solana.PublicKey, username string) (solana.PublicKey, error) {
+	pda, _, err := solana.FindProgramAddress(
+		[][]byte{[]byte("profile"), []byte(username)},
+		program,
+	)
+	return pda, err
+}
+
+func RegisterInstruction(program, user solana.PublicKey, username string) (solana.Instruction, error) {
+	pda, err := AliasPDA(program, username)
+	if err != nil {
+		return nil, err
+	}
+	reverse, err := ReversePDA(program, user)
+	if err != nil {
+		return nil, err
+	}
+	return registryInstruction(program, "register",
+		map[string]solana.PublicKey{
+			"alias":         pda,
+			"reverse":       reverse,
+			"user":          user,
+			"systemProgram": solana.SystemProgramID,
+		},
+		username,
+	)
+}
+
+func UpdateInstruction(program, user solana.PublicKey, username string, newOwner solana.PublicKey) (solana.Instruction, error) {
+	pda, err := AliasPDA(program, username)
+	if err != nil {
+		return nil, err
+	}
+	oldReverse, err := ReversePDA(program, user)
+	if err != nil {
+		return nil, err
+	}
+	newReverse, err := ReversePDA(program, newOwner)
+	if err != nil {
+		return nil, err
+	}
+	return registryInstruction(program, "update",
+		map[string]solana.PublicKey{
+			"alias":         pda,
+			"oldReverse":    oldReverse,
+			"newReverse":    newReverse,
+			"user":          user,
+			"systemProgram": solana.SystemProgramID,
+		},
+		newOwner,
+	)
+}
+
+func SetProfileInstruction(program, user solana.PublicKey, username string, p ProfileAccount) (solana.Instruction, error) {
+	pda, err := AliasPDA(program, username)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := ProfilePDA(program, username)
+	if err != nil {
+		return nil, err
+	}
+	return registryInstruction(program, "setProfile",
+		map[string]solana.PublicKey{
+			"alias":         pda,
+			"profile":       profile,
+			"user":          user,
+			"systemProgram": solana.SystemProgramID,
+		},
+		p.DisplayName, p.URL, p.Token, p.MemoRequired,
+	)
+}
+
+func CloseInstruction(program, user solana.PublicKey, username string) (solana.Instruction, error) {
+	pda, err := AliasPDA(program, username)
+	if err != nil {
+		return nil, err
+	}
+	reverse, err := ReversePDA(program, user)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := ProfilePDA(program, username)
+	if err != nil {
+		return nil, err
+	}
+	return registryInstruction(program, "close",
+		map[string]solana.PublicKey{
+			"alias":   pda,
+			"reverse": reverse,
+			"profile": profile,
+			"user":    user,
+		},
+	)
+}
+
+func registryInstruction(program solana.PublicKey, name string, accounts map[string]solana.PublicKey, args ...any) (solana.Instruction, error) {
+	var ix *idlInstruction
+	for k := range registryIDL.Instructions {
+		if registryIDL.Instructions[k].Name == name {
+			ix = &registryIDL.Instructions[k]
+		}
+	}
+	if ix == nil {
+		return nil, fmt.Errorf("registry idl: no instruction %s", name)
+	}
+	if len(args) != len(ix.Args) {
+		return nil, fmt.Errorf("registry %s: want %d args, got %d", name, len(ix.Args), len(args))
+	}
+
+	var metas solana.AccountMetaSlice
+	for _, a := range ix.Accounts {
+		key, ok := accounts[a.Name]
+		if !ok {
+			return nil, fmt.Errorf("registry %s: missing account %s", name, a.Name)
+		}
+		metas = append(metas, &solana.AccountMeta{PublicKey: key, IsWritable: a.IsMut, IsSigner: a.IsSigner})
+	}
+
+	var data bytes.Buffer
+	data.Write(instructionDiscriminator(name))
+	for k, field := range ix.Args {
+		if err := borshEncode(&data, field.Type, args[k]); err != nil {
+			return nil, fmt.Errorf("registry %s: %s: %w", name, field.Name, err)
+		}
+	}
+
+	return solana.NewInstruction(program, metas, data.Bytes()), nil
+}
+
+func DecodeAlias(data []byte) (AliasAccount, error) {
+	var out AliasAccount
+	err := decodeAccount("Alias", data, map[string]any{
+		"username":  &out.Username,
+		"owner":     &out.Owner,
+		"createdAt": &out.CreatedAt,
+	})
+	return out, err
+}
+
+func DecodeReverse(data []byte) (ReverseAccount, error) {
+	var out ReverseAccount
+	err := decodeAccount("Reverse", data, map[string]any{
+		"username": &out.Username,
+		"owner":    &out.Owner,
+	})
+	return out, err
+}
+
+func DecodeProfile(data []byte) (ProfileAccount, error) {
+	var out ProfileAccount
+	err := decodeAccount("Profile", data, map[string]any{
+		"owner":        &out.Owner,
+		"displayName":  &out.DisplayName,
+		"url":          &out.URL,
+		"token":        &out.Token,
+		"memoRequired": &out.MemoRequired,
+		"updatedAt":    &out.UpdatedAt,
+	})
+	return out, err
+}
+
+func decodeAccount(name string, data []byte, fields map[string]any) error {
+	var acct *idlAccount
+	for k := range registryIDL.Accounts {
+		if registryIDL.Accounts[k].Name == name {
+			acct = &registryIDL.Accounts[k]
+		}
+	}
+	if acct == nil {
+		return fmt.Errorf("registry idl: no account %s", name)
+	}
+
+	if len(data) < 8 || !bytes.Equal(data[:8], accountDiscriminator(name)) {
+		return fmt.Errorf("not a %s account", name)
+	}
+
+	r := bytes.NewReader(data[8:])
+	for _, field := range acct.Type.Fields {
+		dst, ok := fields[field.Name]
+		if !ok {
+			return fmt.Errorf("%s: unknown field %s", name, field.Name)
+		}
+		if err := borshDecode(r, field.Type, dst); err != nil {
+			return fmt.Errorf("%s: %s: %w", name, field.Name, err)
+		}
+	}
+	return nil
+}
+
+// RegistryError maps an Anchor custom error code to the program's message.
+func RegistryError(code int) (string, bool) {
+	for _, e := range registryIDL.Errors {
+		if e.Code == code {
+			return e.Msg, true
+		}
+	}
+	return "", false
+}
+
+var customErrorRe = regexp.MustCompile(`custom program error: 0x([0-9a-fA-F]+)`)
+
+// registryFailure reads the custom error code out of a failed preflight
+// ("... custom program error: 0x1771") and returns the registry's message
+// for it.
+func registryFailure(err error) (string, bool) {
+	var rpcErr *jsonrpc.RPCError
+	if !errors.As(err, &rpcErr) {
+		return "", false
+	}
+	m := customErrorRe.FindStringSubmatch(rpcErr.Message)
+	if m == nil {
+		return "", false
+	}
+	code, perr := strconv.ParseInt(m[1], 16, 32)
+	if perr != nil {
+		return "", false
+	}
+	return RegistryError(int(code))
+}
+
+func borshEncode(w *bytes.Buffer, typ string, v any) error {
+	switch typ {
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("want string, got %T", v)
+		}
+		binary.Write(w, binary.LittleEndian, uint32(len(s)))
+		w.WriteString(s)
+	case "publicKey":
+		pk, ok := v.(solana.PublicKey)
+		if !ok {
+			return fmt.Errorf("want publicKey, got %T", v)
+		}
+		w.Write(pk[:])
+	case "i64":
+		n, ok := v.(int64)
+		if !ok {
+			return fmt.Errorf("want i64, got %T", v)
+		}
+		binary.Write(w, binary.LittleEndian, n)
+	case "bool":
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("want bool, got %T", v)
+		}
+		if b {
+			w.WriteByte(1)
+		} else {
+			w.WriteByte(0)
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", typ)
+	}
+	return nil
+}
+
+func borshDecode(r *bytes.Reader, typ string, dst any) error {
+	switch typ {
+	case "string":
+		var n uint32
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return err
+		}
+		if int(n) > r.Len() {
+			return fmt.Errorf("string length %d out of range", n)
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return err
+		}
+		*dst.(*string) = string(b)
+	case "publicKey":
+		pk := dst.(*solana.PublicKey)
+		if _, err := io.ReadFull(r, pk[:]); err != nil {
+			return err
+		}
+	case "i64":
+		return binary.Read(r, binary.LittleEndian, dst.(*int64))
+	case "bool":
+		b, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b > 1 {
+			return fmt.Errorf("invalid bool %d", b)
+		}
+		*dst.(*bool) = b == 1
+	default:
+		return fmt.Errorf("unsupported type %s", typ)
+	}
+	return nil
+}
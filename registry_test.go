@@ -0,0 +1,198 @@
+package dix
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+func filled(b byte) solana.PublicKey {
+	var pk solana.PublicKey
+	for k := range pk {
+		pk[k] = b
+	}
+	return pk
+}
+
+var (
+	testProgram = filled(3) // CktRuQ2mttgRGkXJtyksdKHjUdc2C4TgDzyB98oEzy8
+	testUser    = filled(1) // 4vJ9JU1bJJE96FWSJKvHsmmFADCg4gpZQff4P3bkLKi
+	testNewUser = filled(2) // 8qbHbw2BbbTHBW1sbeqakYXVKRQM8Ne7pLK7m6CVfeR
+)
+
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestInstructionDiscriminator(t *testing.T) {
+	// sha256("global:<snake_case name>")[:8], as Anchor computes it.
+	for name, want := range map[string]string{
+		"register":   "d37c430fd3c2b2f0",
+		"update":     "dbc858b09e3ffd7f",
+		"setProfile": "ddddc379854771aa",
+		"close":      "62a5c9b16c41ce60",
+	} {
+		if got := hex.EncodeToString(instructionDiscriminator(name)); got != want {
+			t.Errorf("%s: got %s, want %s", name, got, want)
+		}
+	}
+}
+
+type wantMeta struct {
+	key      string
+	writable bool
+	signer   bool
+}
+
+func checkInstruction(t *testing.T, ix solana.Instruction, data string, metas []wantMeta) {
+	t.Helper()
+	if !ix.ProgramID().Equals(testProgram) {
+		t.Errorf("program: got %s", ix.ProgramID())
+	}
+	got, err := ix.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, unhex(t, data)) {
+		t.Errorf("data:\n got %x\nwant %s", got, data)
+	}
+	accounts := ix.Accounts()
+	if len(accounts) != len(metas) {
+		t.Fatalf("accounts: got %d, want %d", len(accounts), len(metas))
+	}
+	for k, m := range metas {
+		a := accounts[k]
+		if a.PublicKey.String() != m.key || a.IsWritable != m.writable || a.IsSigner != m.signer {
+			t.Errorf("account %d: got %s w=%v s=%v, want %s w=%v s=%v",
+				k, a.PublicKey, a.IsWritable, a.IsSigner, m.key, m.writable, m.signer)
+		}
+	}
+}
+
+func TestRegisterInstruction(t *testing.T) {
+	ix, err := RegisterInstruction(testProgram, testUser, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkInstruction(t, ix, "d37c430fd3c2b2f0"+"05000000"+hex.EncodeToString([]byte("alice")), []wantMeta{
+		{"9xKikrHi5aeeU8cPKiQN4XACLUa1yDc3LL7eKkFeQBir", true, false},
+		{"7GHcBoPbYjVdvAPXLJwN9cCdBKMvr9BfncdqMpMpT7Mp", true, false},
+		{testUser.String(), true, true},
+		{solana.SystemProgramID.String(), false, false},
+	})
+}
+
+func TestUpdateInstruction(t *testing.T) {
+	ix, err := UpdateInstruction(testProgram, testUser, "alice", testNewUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkInstruction(t, ix, "dbc858b09e3ffd7f"+strings.Repeat("02", 32), []wantMeta{
+		{"9xKikrHi5aeeU8cPKiQN4XACLUa1yDc3LL7eKkFeQBir", true, false},
+		{"7GHcBoPbYjVdvAPXLJwN9cCdBKMvr9BfncdqMpMpT7Mp", true, false},
+		{"7eeJmYWjXhTuszUS3pevmBxxLgc4ce8vUghmWrn7txB6", true, false},
+		{testUser.String(), true, true},
+		{solana.SystemProgramID.String(), false, false},
+	})
+}
+
+func aliasData(username string, owner solana.PublicKey, createdAt int64) []byte {
+	var b bytes.Buffer
+	b.Write(accountDiscriminator("Alias"))
+	binary.Write(&b, binary.LittleEndian, uint32(len(username)))
+	b.WriteString(username)
+	b.Write(owner[:])
+	binary.Write(&b, binary.LittleEndian, createdAt)
+	return b.Bytes()
+}
+
+func TestDecodeAlias(t *testing.T) {
+	data := aliasData("alice", testUser, 1767225600)
+	if got := hex.EncodeToString(data[:8]); got != "af173122714fe5cc" {
+		t.Fatalf("discriminator: got %s", got)
+	}
+
+	a, err := DecodeAlias(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Username != "alice" || !a.Owner.Equals(testUser) || a.CreatedAt != 1767225600 {
+		t.Fatalf("got %+v", a)
+	}
+
+	for _, n := range []int{0, 7, 8, 11, 14, 40, len(data) - 1} {
+		if _, err := DecodeAlias(data[:n]); err == nil {
+			t.Errorf("truncated to %d bytes: want error", n)
+		}
+	}
+
+	wrong := append([]byte{}, data...)
+	copy(wrong, accountDiscriminator("Reverse"))
+	if _, err := DecodeAlias(wrong); err == nil {
+		t.Error("wrong discriminator: want error")
+	}
+
+	long := append([]byte{}, data...)
+	binary.LittleEndian.PutUint32(long[8:], 1<<20)
+	if _, err := DecodeAlias(long); err == nil {
+		t.Error("oversized string length: want error")
+	}
+}
+
+func TestRegistryFailure(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", &jsonrpc.RPCError{
+		Code:    -32002,
+		Message: "Transaction simulation failed: Error processing Instruction 0: custom program error: 0x1771",
+	})
+	msg, ok := registryFailure(err)
+	if !ok || msg != "Not the owner of this alias" {
+		t.Fatalf("got %q, %v", msg, ok)
+	}
+
+	if _, ok := registryFailure(&jsonrpc.RPCError{Message: "custom program error: 0x1"}); ok {
+		t.Error("non-registry code mapped")
+	}
+	if _, ok := registryFailure(fmt.Errorf("custom program error: 0x1771")); ok {
+		t.Error("non-rpc error mapped")
+	}
+}
+
+func TestReleaseAliasRent(t *testing.T) {
+	for _, withProfile := range []bool{false, true} {
+		rpc, rpcURL, keypair, db := payFixture(t)
+		program := filled(5)
+		aliasPDA, _ := AliasPDA(program, "joao")
+		profilePDA, _ := ProfilePDA(program, "joao")
+
+		rpc.accounts = map[solana.PublicKey]fakeAccount{
+			aliasPDA: {lamports: 1_500_000, data: aliasData("joao", keypair.PublicKey(), 1)},
+		}
+		want := uint64(1_500_000)
+		if withProfile {
+			rpc.accounts[profilePDA] = fakeAccount{lamports: 2_000_000}
+			want += 2_000_000
+		}
+
+		_, rent, err := ReleaseAlias(db, "joao", keypair, program.String(), rpcURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rent != want {
+			t.Errorf("profile=%v: rent %d, want %d", withProfile, rent, want)
+		}
+		if len(rpc.sent) != 1 {
+			t.Errorf("profile=%v: sent %d transactions", withProfile, len(rpc.sent))
+		}
+	}
+}
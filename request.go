@@ -0,0 +1,151 @@
+package dix
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func NewRequest(db *sql.DB, recipient, token string, amount uint64, label, memo string) (PayRequest, error) {
+	if _, ok := Tokens[token]; !ok {
+		return PayRequest{}, fmt.Errorf("token not supported: %s", token)
+	}
+	if _, err := solana.PublicKeyFromBase58(recipient); err != nil {
+		return PayRequest{}, fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	ref := solana.NewWallet().PublicKey()
+
+	r := PayRequest{
+		ID:        ref.String(),
+		Recipient: recipient,
+		Token:     token,
+		Amount:    amount,
+		Label:     label,
+		Memo:      memo,
+		Time:      time.Now().Unix(),
+		Status:    "open",
+	}
+	r.URL = RequestURL(r)
+
+	if err := SaveRequest(db, r); err != nil {
+		return PayRequest{}, err
+	}
+	return r, nil
+}
+
+func RequestURL(r PayRequest) string {
+	q := []string{}
+	if r.Amount > 0 {
+		q = append(q, "amount="+trimAmount(FmtAmount(r.Amount, r.Token)))
+	}
+	q = append(q, "spl-token="+GetTokenMint(r.Token))
+	if r.ID != "" {
+		q = append(q, "reference="+r.ID)
+	}
+	if r.Label != "" {
+		q = append(q, "label="+payEscape(r.Label))
+	}
+	if r.Message != "" {
+		q = append(q, "message="+payEscape(r.Message))
+	}
+	if r.Memo != "" {
+		q = append(q, "memo="+payEscape(r.Memo))
+	}
+	return "solana:" + r.Recipient + "?" + strings.Join(q, "&")
+}
+
+func ParsePayURL(s string) (PayRequest, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(s), "solana:")
+	if !ok {
+		return PayRequest{}, fmt.Errorf("not a solana pay url")
+	}
+
+	recipient, query, _ := strings.Cut(rest, "?")
+	if strings.HasPrefix(recipient, "https") {
+		return PayRequest{}, fmt.Errorf("transaction requests not supported")
+	}
+	if _, err := solana.PublicKeyFromBase58(recipient); err != nil {
+		return PayRequest{}, fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return PayRequest{}, fmt.Errorf("invalid url: %w", err)
+	}
+
+	mint := params.Get("spl-token")
+	if mint == "" {
+		return PayRequest{}, fmt.Errorf("SOL transfer requests not supported")
+	}
+	token, ok := TokenByMint(mint)
+	if !ok {
+		return PayRequest{}, fmt.Errorf("token not supported: %s", mint)
+	}
+
+	r := PayRequest{
+		Recipient: recipient,
+		Token:     token,
+		Label:     params.Get("label"),
+		Message:   params.Get("message"),
+		Memo:      params.Get("memo"),
+		URL:       s,
+	}
+
+	if a := params.Get("amount"); a != "" {
+		r.Amount, err = ParseAmount(a, token)
+		if err != nil {
+			return PayRequest{}, err
+		}
+	}
+
+	refs := params["reference"]
+	for _, ref := range refs {
+		if _, err := solana.PublicKeyFromBase58(ref); err != nil {
+			return PayRequest{}, fmt.Errorf("invalid reference: %w", err)
+		}
+	}
+	r.ID = strings.Join(refs, ",")
+
+	return r, nil
+}
+
+func PayURL(db *sql.DB, keypair solana.PrivateKey, r PayRequest, prices PriceSource, rpcURL string) error {
+	if r.Amount == 0 {
+		return fmt.Errorf("amount cannot be zero")
+	}
+
+	from := keypair.PublicKey()
+	now := time.Now()
+
+	i := Intent{
+		ID:        mkid(from.String(), r.Recipient+":"+r.ID, r.Amount, now.Unix()),
+		From:      from.String(),
+		To:        r.Recipient,
+		Amount:    r.Amount,
+		Token:     r.Token,
+		Time:      now.Unix(),
+		Status:    "pending",
+		Reference: r.ID,
+		Memo:      r.Memo,
+	}
+
+	_, err := PayIntent(db, keypair, i, nil, prices, rpcURL)
+	return err
+}
+
+func trimAmount(s string) string {
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(s, "0")
+		s = strings.TrimSuffix(s, ".")
+	}
+	return s
+}
+
+func payEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
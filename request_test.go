@@ -0,0 +1,48 @@
+package dix
+
+import "testing"
+
+func TestPayURLRoundTrip(t *testing.T) {
+	r := PayRequest{
+		ID:        filled(3).String(),
+		Recipient: filled(7).String(),
+		Token:     "usdc",
+		Amount:    12_500_000,
+		Label:     "Padaria do Joao",
+		Message:   "pedido #42 & troco",
+		Memo:      "fatura 7/2026",
+	}
+	u := RequestURL(r)
+	want := "solana:" + r.Recipient + "?amount=12.5&spl-token=" + GetTokenMint("usdc") +
+		"&reference=" + r.ID + "&label=Padaria%20do%20Joao&message=pedido%20%2342%20%26%20troco&memo=fatura%207%2F2026"
+	if u != want {
+		t.Fatalf("got  %s\nwant %s", u, want)
+	}
+
+	got, err := ParsePayURL(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Recipient != r.Recipient || got.Token != r.Token || got.Amount != r.Amount || got.ID != r.ID ||
+		got.Label != r.Label || got.Message != r.Message || got.Memo != r.Memo || got.URL != u {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestParsePayURLErrors(t *testing.T) {
+	pk := filled(7).String()
+	mint := GetTokenMint("usdc")
+	for name, u := range map[string]string{
+		"scheme":      "bitcoin:" + pk,
+		"transaction": "solana:https://example.com/tx",
+		"recipient":   "solana:nope?spl-token=" + mint,
+		"sol":         "solana:" + pk + "?amount=1",
+		"mint":        "solana:" + pk + "?spl-token=" + filled(9).String(),
+		"amount":      "solana:" + pk + "?amount=abc&spl-token=" + mint,
+		"reference":   "solana:" + pk + "?spl-token=" + mint + "&reference=nope",
+	} {
+		if _, err := ParsePayURL(u); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package dix
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Resolver turns a recipient name into a wallet. It also returns the source
+// label stored on the intent (to_source). amount and token let an
+// implementation decide how fresh the answer has to be.
+type Resolver interface {
+	Resolve(name string, amount uint64, token string) (solana.PublicKey, string, error)
+}
+
+const SourceContacts = "contacts"
+
+// ChainResolver reads the dix registry and SNS through the alias cache.
+type ChainResolver struct {
+	DB        *sql.DB
+	ProgramID string
+	RPC       string
+}
+
+func (c ChainResolver) Resolve(name string, amount uint64, token string) (solana.PublicKey, string, error) {
+	owner, err := ResolveForAmount(c.DB, name, amount, token, c.ProgramID, c.RPC)
+	if err != nil {
+		return solana.PublicKey{}, "", err
+	}
+	return owner, NameSource(name), nil
+}
+
+// StaticResolver maps names to pubkeys without touching the network.
+type StaticResolver map[string]solana.PublicKey
+
+func (s StaticResolver) Resolve(name string, amount uint64, token string) (solana.PublicKey, string, error) {
+	pk, ok := s[strings.ToLower(name)]
+	if !ok {
+		return solana.PublicKey{}, "", fmt.Errorf("%w: %s", errAliasNotFound, name)
+	}
+	return pk, SourceContacts, nil
+}
+
+// MultiResolver asks each resolver in order and stops at the first that
+// knows the name. Any other error stops the chain, so a directory outage
+// does not fall through to a registry name it was meant to shadow.
+type MultiResolver []Resolver
+
+func (m MultiResolver) Resolve(name string, amount uint64, token string) (solana.PublicKey, string, error) {
+	err := fmt.Errorf("%w: %s", errAliasNotFound, name)
+	for _, r := range m {
+		pk, src, e := r.Resolve(name, amount, token)
+		if e == nil || !errors.Is(e, errAliasNotFound) {
+			return pk, src, e
+		}
+		err = e
+	}
+	return solana.PublicKey{}, "", err
+}
+
+// ResolverFor asks dir, when set, before the registry and SNS. dir is
+// usually a StaticResolver loaded with LoadContacts, or a team's own
+// directory.
+func ResolverFor(db *sql.DB, dir Resolver, programID, rpcURL string) Resolver {
+	chain := ChainResolver{DB: db, ProgramID: programID, RPC: rpcURL}
+	if dir == nil {
+		return chain
+	}
+	return MultiResolver{dir, chain}
+}
+
+// LoadContacts reads name,pubkey rows. Names follow the username rules and
+// take precedence over registry usernames of the same name.
+func LoadContacts(path string) (StaticResolver, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+
+	contacts := StaticResolver{}
+	var errs []string
+	for n := 0; ; n++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("contacts: %w", err)
+		}
+		line, _ := r.FieldPos(0)
+
+		name := strings.ToLower(strings.TrimSpace(rec[0]))
+		if n == 0 && name == "name" {
+			continue
+		}
+		if len(rec) != 2 {
+			errs = append(errs, fmt.Sprintf("line %d: expected name,pubkey", line))
+			continue
+		}
+		if !IsUsername(name) {
+			errs = append(errs, fmt.Sprintf("line %d: invalid name: %s", line, name))
+			continue
+		}
+		pk, err := solana.PublicKeyFromBase58(strings.TrimSpace(rec[1]))
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("line %d: invalid pubkey: %s", line, rec[1]))
+			continue
+		}
+		contacts[name] = pk
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid contacts %s:\n  %s", path, strings.Join(errs, "\n  "))
+	}
+	return contacts, nil
+}
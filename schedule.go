@@ -0,0 +1,296 @@
+package dix
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+type rule struct {
+	every  time.Duration
+	start  time.Time
+	cron   [5]map[int]bool
+	isCron bool
+}
+
+var cronRanges = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+
+func AddSchedule(db *sql.DB, to, token string, amount uint64, memo, ruleText string, start time.Time) (Schedule, error) {
+	if _, ok := Tokens[token]; !ok {
+		return Schedule{}, fmt.Errorf("token not supported: %s", token)
+	}
+	if amount == 0 {
+		return Schedule{}, fmt.Errorf("amount cannot be zero")
+	}
+	if !IsName(to) {
+		if _, err := solana.PublicKeyFromBase58(to); err != nil {
+			return Schedule{}, fmt.Errorf("invalid recipient: %s", to)
+		}
+	}
+	if err := CheckMemo(memo); err != nil {
+		return Schedule{}, err
+	}
+
+	r, err := parseRule(ruleText, start)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	now := time.Now()
+	s := Schedule{
+		ID:        mkScheduleID(to, token, amount, ruleText, now.UnixNano()),
+		To:        to,
+		Token:     token,
+		Amount:    amount,
+		Memo:      memo,
+		Rule:      ruleText,
+		Status:    "active",
+		CreatedAt: now.Unix(),
+	}
+	if r.isCron {
+		s.Next = r.next(start.Add(-time.Minute)).Unix()
+	} else {
+		s.Next = start.Unix()
+	}
+
+	if err := SaveSchedule(db, s); err != nil {
+		return Schedule{}, err
+	}
+	return s, nil
+}
+
+func SetScheduleStatus(db *sql.DB, id, status string) error {
+	s, err := LoadSchedule(db, id)
+	if err != nil {
+		return fmt.Errorf("schedule not found: %s", id)
+	}
+	s.Status = status
+	return SaveSchedule(db, s)
+}
+
+func RunSchedules(db *sql.DB, keypair solana.PrivateKey, now time.Time, catchUp bool, resolver Resolver, prices PriceSource, rpcURL string) error {
+	schedules, err := ListSchedules(db)
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, s := range schedules {
+		if s.Status != "active" || s.Next > now.Unix() {
+			continue
+		}
+
+		r, err := parseRule(s.Rule, time.Unix(s.Next, 0))
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", s.ID, err))
+			continue
+		}
+
+		var due []int64
+		for at := s.Next; at <= now.Unix(); at = r.next(time.Unix(at, 0)).Unix() {
+			due = append(due, at)
+		}
+		if !catchUp && len(due) > 1 {
+			fmt.Printf("schedule %s: skipping %d missed occurrences\n", s.ID, len(due)-1)
+			due = due[len(due)-1:]
+		}
+
+		for _, at := range due {
+			fmt.Printf("schedule %s: %s %s -> %s (due %s)\n",
+				s.ID, FmtAmount(s.Amount, s.Token), GetTokenSymbol(s.Token), s.To,
+				time.Unix(at, 0).Format("2006-01-02 15:04"))
+
+			i := Intent{
+				ID:         mkScheduleIntentID(s.ID, at),
+				From:       keypair.PublicKey().String(),
+				To:         s.To,
+				Amount:     s.Amount,
+				Token:      s.Token,
+				Time:       now.Unix(),
+				Status:     "pending",
+				Memo:       s.Memo,
+				ScheduleID: s.ID,
+			}
+
+			i, err := PayIntent(db, keypair, i, resolver, prices, rpcURL)
+			if err == nil && i.Status != "done" {
+				err = fmt.Errorf("not settled (status: %s)", i.Status)
+			}
+			if err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %v", s.ID, err))
+				break
+			}
+
+			s.Next = r.next(time.Unix(at, 0)).Unix()
+			s.LastRun = now.Unix()
+			if err := SaveSchedule(db, s); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("schedules failed:\n  %s", strings.Join(failed, "\n  "))
+	}
+	return nil
+}
+
+func NextRun(ruleText string, after time.Time) (time.Time, error) {
+	r, err := parseRule(ruleText, after)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return r.next(after), nil
+}
+
+func parseRule(text string, start time.Time) (rule, error) {
+	text = strings.TrimSpace(text)
+
+	if every, ok := strings.CutPrefix(text, "every "); ok {
+		d, err := parseEvery(strings.TrimSpace(every))
+		if err != nil {
+			return rule{}, err
+		}
+		if d < time.Minute {
+			return rule{}, fmt.Errorf("interval too short: %s (min 1m)", every)
+		}
+		return rule{every: d, start: start}, nil
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) != 5 {
+		return rule{}, fmt.Errorf("invalid rule: %q (use \"every 24h\" or a cron expression like \"0 9 5 * *\")", text)
+	}
+
+	r := rule{isCron: true}
+	for k, f := range fields {
+		set, err := parseCronField(f, cronRanges[k][0], cronRanges[k][1])
+		if err != nil {
+			return rule{}, fmt.Errorf("invalid cron field %q: %w", f, err)
+		}
+		r.cron[k] = set
+	}
+	return r, nil
+}
+
+func parseEvery(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid interval: %s", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval: %s", s)
+	}
+	return d, nil
+}
+
+func parseCronField(f string, lo, hi int) (map[int]bool, error) {
+	if f == "*" {
+		return nil, nil
+	}
+
+	set := map[int]bool{}
+	for _, part := range strings.Split(f, ",") {
+		step := 1
+		if base, s, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(s)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("bad step %q", s)
+			}
+			step = n
+			part = base
+		}
+
+		from, to := lo, hi
+		if part != "*" {
+			a, b, isRange := strings.Cut(part, "-")
+			n, err := strconv.Atoi(a)
+			if err != nil {
+				return nil, fmt.Errorf("bad value %q", a)
+			}
+			from, to = n, n
+			if isRange {
+				m, err := strconv.Atoi(b)
+				if err != nil {
+					return nil, fmt.Errorf("bad value %q", b)
+				}
+				to = m
+			} else if step > 1 {
+				to = hi
+			}
+		}
+		if from < lo || to > hi || from > to {
+			return nil, fmt.Errorf("out of range %d-%d", lo, hi)
+		}
+
+		for v := from; v <= to; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+func (r rule) next(after time.Time) time.Time {
+	if !r.isCron {
+		if after.Before(r.start) {
+			return r.start
+		}
+		n := after.Sub(r.start)/r.every + 1
+		return r.start.Add(n * r.every)
+	}
+
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !match(r.cron[3], int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !r.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !match(r.cron[1], t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !match(r.cron[0], t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return limit
+}
+
+func (r rule) dayMatches(t time.Time) bool {
+	dom, dow := r.cron[2], r.cron[4]
+	if dom != nil && dow != nil {
+		return dom[t.Day()] || dow[int(t.Weekday())]
+	}
+	return match(dom, t.Day()) && match(dow, int(t.Weekday()))
+}
+
+func match(set map[int]bool, v int) bool {
+	return set == nil || set[v]
+}
+
+func mkScheduleID(to, token string, amount uint64, rule string, ts int64) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s:%s:%d:%s:%d", to, token, amount, rule, ts)))
+	return hex.EncodeToString(h[:])[:8]
+}
+
+func mkScheduleIntentID(scheduleID string, at int64) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("schedule:%s:%d", scheduleID, at)))
+	return hex.EncodeToString(h[:])[:16]
+}
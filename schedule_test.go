@@ -0,0 +1,114 @@
+package dix
+
+import (
+	"testing"
+	"time"
+)
+
+func at(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestRuleNext(t *testing.T) {
+	start := at("2026-03-01 10:00")
+	for _, tc := range []struct {
+		rule, after, want string
+	}{
+		// day of month
+		{"0 9 5 * *", "2026-03-04 23:59", "2026-03-05 09:00"},
+		{"0 9 5 * *", "2026-03-05 09:00", "2026-04-05 09:00"},
+		{"0 9 1,15 * *", "2026-03-01 09:00", "2026-03-15 09:00"},
+		// months without the day are skipped
+		{"0 0 31 * *", "2026-04-01 00:00", "2026-05-31 00:00"},
+		{"0 0 29 2 *", "2026-03-01 00:00", "2028-02-29 00:00"},
+		// year rollover
+		{"30 8 1 1 *", "2026-06-01 00:00", "2027-01-01 08:30"},
+		{"0 12 * * *", "2026-12-31 12:00", "2027-01-01 12:00"},
+		// day of week, and day of month OR day of week when both are set
+		{"0 9 * * 5", "2026-03-02 00:00", "2026-03-06 09:00"},
+		{"0 9 10 * 1", "2026-03-02 09:00", "2026-03-09 09:00"},
+		{"0 9 10 * 1", "2026-03-09 09:00", "2026-03-10 09:00"},
+		// ranges
+		{"0 9-17 * * 1-5", "2026-03-06 12:30", "2026-03-06 13:00"},
+		{"0 9-17 * * 1-5", "2026-03-06 17:00", "2026-03-09 09:00"},
+		// steps
+		{"*/15 * * * *", "2026-03-02 10:07", "2026-03-02 10:15"},
+		{"5/20 * * * *", "2026-03-02 10:46", "2026-03-02 11:05"},
+		{"0 */6 * * *", "2026-03-02 13:00", "2026-03-02 18:00"},
+		{"0 0 1-10/3 * *", "2026-03-02 00:00", "2026-03-04 00:00"},
+		{"0 0 1-10/3 * *", "2026-03-10 00:00", "2026-04-01 00:00"},
+		// intervals, anchored at the start
+		{"every 24h", "2026-02-01 00:00", "2026-03-01 10:00"},
+		{"every 24h", "2026-03-01 10:00", "2026-03-02 10:00"},
+		{"every 24h", "2026-03-03 15:00", "2026-03-04 10:00"},
+		{"every 7d", "2026-03-02 00:00", "2026-03-08 10:00"},
+		{"every 90m", "2026-03-01 11:29", "2026-03-01 11:30"},
+	} {
+		r, err := parseRule(tc.rule, start)
+		if err != nil {
+			t.Errorf("%s: %v", tc.rule, err)
+			continue
+		}
+		if got := r.next(at(tc.after)); !got.Equal(at(tc.want)) {
+			t.Errorf("%s after %s: got %s, want %s", tc.rule, tc.after, got.Format("2006-01-02 15:04"), tc.want)
+		}
+	}
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"* * *",
+		"0 9 32 * *",
+		"60 * * * *",
+		"0 24 * * *",
+		"0 9 * 13 *",
+		"0 9 * * 7",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"every 30s",
+		"every soon",
+		"every xd",
+	} {
+		if _, err := parseRule(text, time.Now()); err == nil {
+			t.Errorf("%q: want error", text)
+		}
+	}
+}
+
+func TestRunSchedulesSkipsMissed(t *testing.T) {
+	for _, catchUp := range []bool{false, true} {
+		rpc, rpcURL, keypair, db := payFixture(t)
+		start := time.Now().Truncate(time.Minute).Add(-5 * time.Hour)
+
+		s, err := AddSchedule(db, filled(7).String(), "usdc", 1_000_000, "", "every 1h", start)
+		if err != nil {
+			t.Fatal(err)
+		}
+		now := start.Add(5*time.Hour + 10*time.Minute)
+		if err := RunSchedules(db, keypair, now, catchUp, StaticResolver{}, nil, rpcURL); err != nil {
+			t.Fatal(err)
+		}
+
+		want := 1
+		if catchUp {
+			want = 6
+		}
+		if len(rpc.sent) != want {
+			t.Errorf("catchUp=%v: sent %d payments, want %d", catchUp, len(rpc.sent), want)
+		}
+		s, _ = LoadSchedule(db, s.ID)
+		if next := start.Add(6 * time.Hour).Unix(); s.Next != next {
+			t.Errorf("catchUp=%v: next %s, want %s", catchUp, time.Unix(s.Next, 0), time.Unix(next, 0))
+		}
+
+		if err := RunSchedules(db, keypair, now, catchUp, StaticResolver{}, nil, rpcURL); err != nil || len(rpc.sent) != want {
+			t.Errorf("catchUp=%v: second run paid again (%d sent, %v)", catchUp, len(rpc.sent), err)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package dix
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Solana Name Service (.sol domains). A domain account is a PDA of the name
+// service program derived from the hashed name and its parent: the .sol TLD
+// for "alice.sol", the domain account for "pay.alice.sol". Only the TLD is
+// pinned; the program is read from the owner of the TLD account.
+var solTLD = solana.MustPublicKeyFromBase58("58PwtjSDuFHuUkYjH9BYnnQKHfwo9reZhC2zMJv9JPkx")
+
+const snsHashPrefix = "SPL Name Service"
+
+var (
+	snsMu      sync.Mutex
+	snsProgram solana.PublicKey
+)
+
+func nameServiceProgram(client *rpc.Client) (solana.PublicKey, error) {
+	snsMu.Lock()
+	defer snsMu.Unlock()
+
+	if !snsProgram.IsZero() {
+		return snsProgram, nil
+	}
+	acct, err := client.GetAccountInfo(context.Background(), solTLD)
+	if err == nil && acct.Value == nil {
+		err = rpc.ErrNotFound
+	}
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf(".sol tld: %w", err)
+	}
+	snsProgram = acct.Value.Owner
+	return snsProgram, nil
+}
+
+// IsSNSDomain accepts "name.sol" and one subdomain level ("sub.name.sol").
+func IsSNSDomain(s string) bool {
+	name, ok := strings.CutSuffix(s, ".sol")
+	if !ok {
+		return false
+	}
+	labels := strings.Split(name, ".")
+	if len(labels) > 2 {
+		return false
+	}
+	for _, l := range labels {
+		if l == "" {
+			return false
+		}
+		for _, c := range l {
+			if !((c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func snsNameAccount(program solana.PublicKey, name string, parent solana.PublicKey) (solana.PublicKey, error) {
+	hashed := sha256.Sum256([]byte(snsHashPrefix + name))
+	pda, _, err := solana.FindProgramAddress(
+		[][]byte{hashed[:], make([]byte, 32), parent[:]},
+		program,
+	)
+	return pda, err
+}
+
+// SNSDomainKey derives the name account of a .sol domain.
+func SNSDomainKey(program solana.PublicKey, domain string) (solana.PublicKey, error) {
+	if !IsSNSDomain(domain) {
+		return solana.PublicKey{}, fmt.Errorf("invalid .sol domain: %s", domain)
+	}
+
+	labels := strings.Split(strings.TrimSuffix(domain, ".sol"), ".")
+	key, err := snsNameAccount(program, labels[len(labels)-1], solTLD)
+	if err != nil || len(labels) == 1 {
+		return key, err
+	}
+	return snsNameAccount(program, "\x00"+labels[0], key)
+}
+
+// ResolveSNS reads the owner of a .sol domain from its name record header
+// (parent, owner, class) and refreshes the cache.
+func ResolveSNS(db *sql.DB, domain string, rpcURL string) (solana.PublicKey, error) {
+	domain = strings.ToLower(domain)
+
+	client := rpc.New(rpcURL)
+	program, err := nameServiceProgram(client)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+	key, err := SNSDomainKey(program, domain)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+
+	acct, err := client.GetAccountInfo(context.Background(), key)
+	if errors.Is(err, rpc.ErrNotFound) || (err == nil && acct.Value == nil) {
+		return solana.PublicKey{}, fmt.Errorf("%w: %s", errAliasNotFound, domain)
+	}
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("resolve %s: %w", domain, err)
+	}
+	if !acct.Value.Owner.Equals(program) {
+		return solana.PublicKey{}, fmt.Errorf("%s: not a name service account", domain)
+	}
+
+	data := acct.Value.Data.GetBinary()
+	if len(data) < 96 {
+		return solana.PublicKey{}, fmt.Errorf("%s: short name record", domain)
+	}
+	owner := solana.PublicKeyFromBytes(data[32:64])
+	if owner.IsZero() {
+		return solana.PublicKey{}, fmt.Errorf("%w: %s", errAliasNotFound, domain)
+	}
+
+	Savealiascache(db, AliasCache{
+		Username:  domain,
+		Owner:     owner.String(),
+		FetchedAt: time.Now().Unix(),
+		Slot:      acct.Context.Slot,
+		Source:    SourceSNS,
+	})
+
+	return owner, nil
+}
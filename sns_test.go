@@ -0,0 +1,29 @@
+package dix
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestSNSDomainKey(t *testing.T) {
+	program := solana.MustPublicKeyFromBase58("namesLPneVptA9Z5rqUDD9tMTWEJwofgaYwp8cawRkX")
+	for domain, want := range map[string]string{
+		"bonfida.sol":     "Crf8hzfthWGbGbLTVCiqRqV5MVnbpHB1L9KQMd6gsinb",
+		"dex.bonfida.sol": "HoFfFXqFHAC8RP3duuQNzag1ieUwJRBv1HtRNiWFq4Qu",
+	} {
+		got, err := SNSDomainKey(program, domain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != want {
+			t.Errorf("%s: got %s, want %s", domain, got, want)
+		}
+	}
+
+	for _, domain := range []string{"bonfida", "a.b.bonfida.sol", ".sol", "Bonfida.sol"} {
+		if _, err := SNSDomainKey(program, domain); err == nil {
+			t.Errorf("%s: want error", domain)
+		}
+	}
+}
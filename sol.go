@@ -3,6 +3,7 @@ package dix
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gagliardetto/solana-go"
@@ -11,39 +12,84 @@ import (
 )
 
 func Send(from solana.PublicKey, to solana.PublicKey, amount uint64, tokenKey string, keypair solana.PrivateKey, rpcURL string) (string, error) {
-	client := rpc.New(rpcURL)
-	mint := solana.MustPublicKeyFromBase58(GetTokenMint(tokenKey))
+	tx, err := signTransfer(from, to, Intent{Amount: amount, Token: tokenKey}, keypair, rpcURL)
+	if err != nil {
+		return "", err
+	}
+	return submit(tx, rpcURL)
+}
+
+func signTransfer(from solana.PublicKey, to solana.PublicKey, i Intent, keypair solana.PrivateKey, rpcURL string) (*solana.Transaction, error) {
+	instructions, err := transferInstructions(from, to, i)
+	if err != nil {
+		return nil, err
+	}
+
+	recent, err := latestBlockhash(rpcURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return signInstructions(instructions, recent, keypair)
+}
+
+func transferInstructions(from solana.PublicKey, to solana.PublicKey, i Intent) ([]solana.Instruction, error) {
+	mint := solana.MustPublicKeyFromBase58(GetTokenMint(i.Token))
 
 	fromATA, _, err := solana.FindAssociatedTokenAddress(from, mint)
 	if err != nil {
-		return "", fmt.Errorf("from ATA: %w", err)
+		return nil, fmt.Errorf("from ATA: %w", err)
 	}
 
 	toATA, _, err := solana.FindAssociatedTokenAddress(to, mint)
 	if err != nil {
-		return "", fmt.Errorf("to ATA: %w", err)
+		return nil, fmt.Errorf("to ATA: %w", err)
 	}
 
 	transferIx := token.NewTransferInstruction(
-		amount,
+		i.Amount,
 		fromATA,
 		toATA,
 		from,
 		[]solana.PublicKey{},
 	).Build()
 
+	instructions := []solana.Instruction{}
+	if i.Memo != "" {
+		instructions = append(instructions, memoInstruction(from, i.Memo))
+	}
+	if i.Reference != "" {
+		withRefs, err := addReferences(transferIx, strings.Split(i.Reference, ","))
+		if err != nil {
+			return nil, err
+		}
+		instructions = append(instructions, withRefs)
+	} else {
+		instructions = append(instructions, transferIx)
+	}
+
+	return instructions, nil
+}
+
+func latestBlockhash(rpcURL string) (solana.Hash, error) {
+	client := rpc.New(rpcURL)
 	recent, err := client.GetLatestBlockhash(context.Background(), rpc.CommitmentFinalized)
 	if err != nil {
-		return "", fmt.Errorf("blockhash: %w", err)
+		return solana.Hash{}, fmt.Errorf("blockhash: %w", err)
 	}
+	return recent.Value.Blockhash, nil
+}
+
+func signInstructions(instructions []solana.Instruction, recent solana.Hash, keypair solana.PrivateKey) (*solana.Transaction, error) {
+	from := keypair.PublicKey()
 
 	tx, err := solana.NewTransaction(
-		[]solana.Instruction{transferIx},
-		recent.Value.Blockhash,
+		instructions,
+		recent,
 		solana.TransactionPayer(from),
 	)
 	if err != nil {
-		return "", fmt.Errorf("tx: %w", err)
+		return nil, fmt.Errorf("tx: %w", err)
 	}
 
 	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
@@ -53,10 +99,55 @@ func Send(from solana.PublicKey, to solana.PublicKey, amount uint64, tokenKey st
 		return nil
 	})
 	if err != nil {
-		return "", fmt.Errorf("sign: %w", err)
+		return nil, fmt.Errorf("sign: %w", err)
+	}
+
+	return tx, nil
+}
+
+func txSize(instructions []solana.Instruction, payer solana.PublicKey) (int, error) {
+	tx, err := solana.NewTransaction(instructions, solana.Hash{}, solana.TransactionPayer(payer))
+	if err != nil {
+		return 0, err
+	}
+	msg, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	signers := int(tx.Message.Header.NumRequiredSignatures)
+	return 1 + signers*64 + len(msg), nil
+}
+
+func memoInstruction(signer solana.PublicKey, memo string) solana.Instruction {
+	return solana.NewInstruction(
+		solana.MemoProgramID,
+		solana.AccountMetaSlice{{PublicKey: signer, IsSigner: true}},
+		[]byte(memo),
+	)
+}
+
+func addReferences(ix solana.Instruction, refs []string) (solana.Instruction, error) {
+	data, err := ix.Data()
+	if err != nil {
+		return nil, err
 	}
+	accounts := solana.AccountMetaSlice(ix.Accounts())
+	for _, r := range refs {
+		ref, err := solana.PublicKeyFromBase58(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reference: %w", err)
+		}
+		accounts = append(accounts, &solana.AccountMeta{PublicKey: ref})
+	}
+	return solana.NewInstruction(ix.ProgramID(), accounts, data), nil
+}
 
+func submit(tx *solana.Transaction, rpcURL string) (string, error) {
+	client := rpc.New(rpcURL)
 	sig, err := client.SendTransaction(context.Background(), tx)
+	if msg, ok := registryFailure(err); ok {
+		return "", fmt.Errorf("send: %s", msg)
+	}
 	if err != nil {
 		return "", fmt.Errorf("send: %w", err)
 	}
@@ -78,6 +169,9 @@ func Confirm(sig string, rpcURL string, timeout time.Duration) error {
 		default:
 			status, err := client.GetSignatureStatuses(ctx, false, signature)
 			if err == nil && len(status.Value) > 0 && status.Value[0] != nil {
+				if status.Value[0].Err != nil {
+					return fmt.Errorf("tx failed: %v", status.Value[0].Err)
+				}
 				if status.Value[0].ConfirmationStatus == rpc.ConfirmationStatusFinalized ||
 					status.Value[0].ConfirmationStatus == rpc.ConfirmationStatusConfirmed {
 					return nil
@@ -88,6 +182,57 @@ func Confirm(sig string, rpcURL string, timeout time.Duration) error {
 	}
 }
 
+func sigStatus(sig string, rpcURL string) (string, error) {
+	client := rpc.New(rpcURL)
+	signature, err := solana.SignatureFromBase58(sig)
+	if err != nil {
+		return "", err
+	}
+
+	status, err := client.GetSignatureStatuses(context.Background(), true, signature)
+	if err != nil {
+		return "", err
+	}
+	if len(status.Value) == 0 || status.Value[0] == nil {
+		return "unknown", nil
+	}
+	if status.Value[0].Err != nil {
+		return "failed", nil
+	}
+	return "landed", nil
+}
+
+func txFee(sig string, rpcURL string) (uint64, error) {
+	client := rpc.New(rpcURL)
+	signature, err := solana.SignatureFromBase58(sig)
+	if err != nil {
+		return 0, err
+	}
+
+	version := uint64(0)
+	out, err := client.GetTransaction(context.Background(), signature, &rpc.GetTransactionOpts{
+		Commitment:                     rpc.CommitmentConfirmed,
+		MaxSupportedTransactionVersion: &version,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if out.Meta == nil {
+		return 0, fmt.Errorf("no meta for %s", sig)
+	}
+	return out.Meta.Fee, nil
+}
+
+// associatedTokenAddress derives the ATA for a given token program;
+// solana.FindAssociatedTokenAddress always uses the legacy one.
+func associatedTokenAddress(owner, mint, program solana.PublicKey) (solana.PublicKey, error) {
+	ata, _, err := solana.FindProgramAddress(
+		[][]byte{owner[:], program[:], mint[:]},
+		solana.SPLAssociatedTokenAccountProgramID,
+	)
+	return ata, err
+}
+
 func Balance(pubkey solana.PublicKey, tokenKey string, rpcURL string) (uint64, error) {
 	client := rpc.New(rpcURL)
 	mint := solana.MustPublicKeyFromBase58(GetTokenMint(tokenKey))
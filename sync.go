@@ -0,0 +1,187 @@
+package dix
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+type SyncResult struct {
+	Scanned int
+	Added   int
+	Updated int
+}
+
+// Sync walks the signature history of every token account owned by owner and
+// upserts the transfers it finds. Outgoing transfers already recorded by Pay
+// are matched by signature instead of duplicated. Unless full is set, each
+// account is only scanned back to the newest signature of the previous sync.
+func Sync(db *sql.DB, owner solana.PublicKey, full bool, programID, rpcURL string) (SyncResult, error) {
+	client := rpc.New(rpcURL)
+	var res SyncResult
+
+	for _, key := range TokenKeys() {
+		mint := solana.MustPublicKeyFromBase58(GetTokenMint(key))
+		ata, _, err := solana.FindAssociatedTokenAddress(owner, mint)
+		if err != nil {
+			return res, err
+		}
+
+		cursorKey := "sync:" + ata.String()
+		var until solana.Signature
+		if !full {
+			if c, err := GetCursor(db, cursorKey); err == nil && c != "" {
+				until = solana.MustSignatureFromBase58(c)
+			}
+		}
+
+		sigs, err := walkSignatures(client, ata, until, 0)
+		if err != nil {
+			return res, fmt.Errorf("signatures %s: %w", GetTokenSymbol(key), err)
+		}
+
+		for k := len(sigs) - 1; k >= 0; k-- {
+			s := sigs[k]
+			res.Scanned++
+			if s.Err != nil {
+				continue
+			}
+
+			transfers, err := TxTransfers(owner, s.Signature, rpcURL)
+			if err != nil {
+				return res, fmt.Errorf("tx %s: %w", s.Signature.String()[:16], err)
+			}
+			for _, t := range transfers {
+				if t.Account != ata.String() {
+					continue
+				}
+				added, updated, err := syncTransfer(db, t, programID, rpcURL)
+				if err != nil {
+					return res, err
+				}
+				if added {
+					res.Added++
+				}
+				if updated {
+					res.Updated++
+				}
+			}
+		}
+
+		if len(sigs) > 0 {
+			SaveCursor(db, cursorKey, sigs[0].Signature.String())
+		}
+	}
+
+	return res, nil
+}
+
+// walkSignatures pages backwards through the history of address, newest
+// first, stopping at until or at the first signature older than since.
+func walkSignatures(client *rpc.Client, address solana.PublicKey, until solana.Signature, since int64) ([]*rpc.TransactionSignature, error) {
+	var sigs []*rpc.TransactionSignature
+	var before solana.Signature
+	for {
+		limit := 1000
+		page, err := client.GetSignaturesForAddressWithOpts(context.Background(), address, &rpc.GetSignaturesForAddressOpts{
+			Limit:      &limit,
+			Before:     before,
+			Until:      until,
+			Commitment: rpc.CommitmentConfirmed,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range page {
+			if since > 0 && s.BlockTime != nil && int64(*s.BlockTime) < since {
+				return sigs, nil
+			}
+			sigs = append(sigs, s)
+		}
+		if len(page) < limit {
+			return sigs, nil
+		}
+		before = page[len(page)-1].Signature
+	}
+}
+
+func syncTransfer(db *sql.DB, t Transfer, programID, rpcURL string) (bool, bool, error) {
+	if t.Token == "" {
+		return false, false, nil
+	}
+	if t.Direction == "in" {
+		_, added, err := recordIncoming(db, t, programID, rpcURL)
+		return added, false, err
+	}
+
+	local, err := intentsBySignature(db, t.Signature)
+	if err != nil {
+		return false, false, err
+	}
+
+	var mine []Intent
+	hasFee := false
+	for _, i := range local {
+		if direction(i) == "out" && i.Token == t.Token {
+			mine = append(mine, i)
+			hasFee = hasFee || i.Fee > 0
+		}
+	}
+
+	if len(mine) == 0 {
+		if _, err := Load(db, chainID(t.Signature, t.Account)); err == nil {
+			return false, false, nil
+		}
+		to := t.Counterparty
+		if alias, err := ReverseResolve(db, t.Counterparty, programID, rpcURL); err == nil {
+			to = alias
+		}
+		i := Intent{
+			ID:         chainID(t.Signature, t.Account),
+			From:       t.Owner,
+			To:         to,
+			ToResolved: t.Counterparty,
+			Amount:     t.Amount,
+			Token:      t.Token,
+			Signature:  t.Signature,
+			Time:       t.Time,
+			Status:     "done",
+			Memo:       t.Memo,
+			Direction:  "out",
+			Fee:        t.Fee,
+		}
+		return true, false, Save(db, i)
+	}
+
+	updated := false
+	for n, i := range mine {
+		changed := false
+		if i.Status != "done" {
+			i.Status = "done"
+			changed = true
+		}
+		if n == 0 && !hasFee && t.Fee > 0 {
+			i.Fee = t.Fee
+			changed = true
+		}
+		if changed {
+			if err := Save(db, i); err != nil {
+				return false, updated, err
+			}
+			updated = true
+		}
+	}
+	return false, updated, nil
+}
+
+func intentsBySignature(db *sql.DB, sig string) ([]Intent, error) {
+	rows, err := db.Query(`SELECT `+intentCols+` FROM intents WHERE signature = ? ORDER BY id`, sig)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanIntents(rows)
+}
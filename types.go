@@ -1,15 +1,94 @@
 package dix
 
+import "sort"
+
 type Intent struct {
-	ID         string
-	From       string
-	To         string
-	ToResolved string
-	Amount     uint64
-	Token      string
-	Signature  string
-	Time       int64
-	Status     string
+	ID         string `json:"id"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	ToResolved string `json:"to_resolved"`
+	Amount     uint64 `json:"amount"`
+	Token      string `json:"token"`
+	Signature  string `json:"signature"`
+	Time       int64  `json:"time"`
+	Status     string `json:"status"`
+	PoolID     string `json:"pool_id,omitempty"`
+	Round      int    `json:"round,omitempty"`
+	Reference  string `json:"reference,omitempty"`
+	Memo       string `json:"memo,omitempty"`
+	Direction  string `json:"direction"`
+	FromAlias  string `json:"from_alias,omitempty"`
+	BatchID    string `json:"batch_id,omitempty"`
+	ScheduleID string `json:"schedule_id,omitempty"`
+	Fee        uint64 `json:"fee"`
+	ToSource   string `json:"to_source,omitempty"`
+}
+
+type Schedule struct {
+	ID        string
+	To        string
+	Token     string
+	Amount    uint64
+	Memo      string
+	Rule      string
+	Next      int64
+	LastRun   int64
+	Status    string
+	CreatedAt int64
+}
+
+type Batch struct {
+	ID   string
+	File string
+	Rows []BatchRow
+}
+
+type BatchRow struct {
+	Line     int
+	To       string
+	Resolved string
+	Source   string
+	Token    string
+	Amount   uint64
+	Memo     string
+	Seq      int
+	Intent   Intent
+	Err      error
+
+	// Changed is set by ResolveBatch when the name now resolves to a wallet
+	// other than the one it was last paid to. PayBatch leaves the row unpaid
+	// until the caller clears it.
+	Changed *OwnerChangedError
+}
+
+type Transfer struct {
+	Signature    string
+	Account      string
+	Owner        string
+	Counterparty string
+	Token        string
+	Mint         string
+	Amount       uint64
+	Direction    string
+	Memo         string
+	Accounts     []string
+	Fee          uint64
+	Slot         uint64
+	Time         int64
+}
+
+type PayRequest struct {
+	ID        string
+	Recipient string
+	Token     string
+	Amount    uint64
+	Label     string
+	Message   string
+	Memo      string
+	URL       string
+	Time      int64
+	Status    string
+	Signature string
 }
 
 type Wallet struct {
@@ -18,8 +97,25 @@ type Wallet struct {
 }
 
 type Alias struct {
-	Username string
-	Owner    string
+	Username string   `json:"username"`
+	Owner    string   `json:"owner"`
+	Profile  *Profile `json:"profile,omitempty"`
+}
+
+type Profile struct {
+	DisplayName  string `json:"display_name,omitempty"`
+	URL          string `json:"url,omitempty"`
+	Mint         string `json:"mint,omitempty"`
+	MemoRequired bool   `json:"memo_required"`
+	UpdatedAt    int64  `json:"updated_at"`
+}
+
+type AliasCache struct {
+	Username  string
+	Owner     string
+	FetchedAt int64
+	Slot      uint64
+	Source    string
 }
 
 type Config struct {
@@ -30,23 +126,87 @@ type Config struct {
 }
 
 type Pool struct {
-	ID           string
-	Name         string
-	Token        string
-	Contribution uint64
-	Members      []string
-	Round        int
-	CreatedAt    int64
-	Status       string
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Token        string   `json:"token"`
+	Contribution uint64   `json:"contribution"`
+	Members      []string `json:"members"`
+	Round        int      `json:"round"`
+	CreatedAt    int64    `json:"created_at"`
+	Status       string   `json:"status"`
+}
+
+type PoolEvent struct {
+	ID        int64  `json:"id"`
+	PoolID    string `json:"pool_id"`
+	Kind      string `json:"kind"`
+	Round     int    `json:"round"`
+	Username  string `json:"username,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	IntentID  string `json:"intent_id,omitempty"`
+	Time      int64  `json:"time"`
+}
+
+type FiatStamp struct {
+	IntentID string
+	Fiat     string
+	Price    float64
+	Value    int64
+}
+
+type Watch struct {
+	Name      string
+	Pubkey    string
+	CreatedAt int64
+}
+
+type Checkpoint struct {
+	ID       int64
+	Token    string
+	Balance  uint64
+	Expected uint64
+	Time     int64
+}
+
+type Reconciliation struct {
+	Token      string
+	Since      int64
+	Checkpoint uint64
+	In         uint64
+	Out        uint64
+	Fees       uint64
+	Expected   int64
+	Actual     uint64
+	Err        error
+}
+
+type Webhook struct {
+	ID        string
+	URL       string
+	Secret    string
+	Events    []string
+	CreatedAt int64
+}
+
+type OutboxEvent struct {
+	ID        int64
+	WebhookID string
+	Event     string
+	Payload   string
+	Attempts  int
+	NextTry   int64
+	Status    string
+	LastError string
+	CreatedAt int64
 }
 
 type PoolMember struct {
-	PoolID   string
-	Username string
-	Pubkey   string
-	Paid     bool
-	Claimed  bool
-	Order    int
+	PoolID   string `json:"pool_id"`
+	Username string `json:"username"`
+	Pubkey   string `json:"pubkey"`
+	Paid     bool   `json:"paid"`
+	Claimed  bool   `json:"claimed"`
+	Order    int    `json:"order"`
 }
 
 const (
@@ -93,6 +253,24 @@ func GetTokenMint(token string) string {
 	return Tokens["usdc"].Mint
 }
 
+func TokenKeys() []string {
+	keys := make([]string, 0, len(Tokens))
+	for key := range Tokens {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TokenByMint(mint string) (string, bool) {
+	for key, t := range Tokens {
+		if t.Mint == mint {
+			return key, true
+		}
+	}
+	return "", false
+}
+
 func GetTokenDecimals(token string) uint8 {
 	if t, ok := Tokens[token]; ok {
 		return t.Decimals
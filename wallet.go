@@ -77,12 +77,53 @@ func Savewallet(path string, secret, password []byte) error {
 
 	data, err := json.Marshal(map[string]string{
 		"keypair": hex.EncodeToString(enc),
+		"pubkey":  Pubkey(secret),
 	})
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0600)
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic writes to a temp file in the same directory and renames it
+// over path, so a crash never leaves a truncated keystore behind.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// MigrateWallet rewrites a keystore from before the pubkey was stored so
+// read-only commands stop asking for the password. It is a no-op when the
+// pubkey is already there.
+func MigrateWallet(path string, password []byte) (solana.PublicKey, error) {
+	if pk, err := Walletpubkey(path); err == nil {
+		return pk, nil
+	}
+	secret, err := Loadwallet(path, password)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+	if err := Savewallet(path, secret, password); err != nil {
+		return solana.PublicKey{}, err
+	}
+	return Walletpubkey(path)
 }
 
 func Loadwallet(path string, password []byte) ([]byte, error) {
@@ -104,6 +145,26 @@ func Loadwallet(path string, password []byte) ([]byte, error) {
 	return decrypt(enc, password)
 }
 
+// Walletpubkey reads the public key stored next to the encrypted keypair, so
+// read-only commands don't need the password. Keystores written before the
+// pubkey was stored return an error.
+func Walletpubkey(path string) (solana.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+
+	var stored map[string]string
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return solana.PublicKey{}, err
+	}
+
+	if stored["pubkey"] == "" {
+		return solana.PublicKey{}, errors.New("keystore has no pubkey")
+	}
+	return solana.PublicKeyFromBase58(stored["pubkey"])
+}
+
 func Pubkey(secret []byte) string {
 	if len(secret) < 64 {
 		return ""
@@ -0,0 +1,268 @@
+package dix
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var WebhookEvents = []string{
+	"intent.done",
+	"intent.fail",
+	"payment.received",
+	"pool.create",
+	"pool.join",
+	"pool.start",
+	"pool.contribute",
+	"pool.claim",
+	"pool.advance",
+	"pool.done",
+}
+
+const (
+	webhookTimeout     = 10 * time.Second
+	webhookMaxAttempts = 12
+	webhookBackoff     = 30 * time.Second
+	webhookMaxBackoff  = time.Hour
+)
+
+func AddWebhook(db *sql.DB, rawURL string, events []string) (Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Webhook{}, fmt.Errorf("invalid webhook url: %s", rawURL)
+	}
+
+	if len(events) == 0 {
+		events = []string{"*"}
+	}
+	for _, e := range events {
+		if !validEvent(e) {
+			return Webhook{}, fmt.Errorf("unknown event: %s", e)
+		}
+	}
+
+	secret, err := randHex(32)
+	if err != nil {
+		return Webhook{}, err
+	}
+	id, err := randHex(4)
+	if err != nil {
+		return Webhook{}, err
+	}
+
+	w := Webhook{
+		ID:        id,
+		URL:       rawURL,
+		Secret:    secret,
+		Events:    events,
+		CreatedAt: time.Now().Unix(),
+	}
+	if err := SaveWebhook(db, w); err != nil {
+		return Webhook{}, err
+	}
+	return w, nil
+}
+
+func Emit(db *sql.DB, event string, data any) error {
+	hooks, err := ListWebhooks(db)
+	if err != nil || len(hooks) == 0 {
+		return err
+	}
+
+	id, err := randHex(8)
+	if err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	payload, err := json.Marshal(map[string]any{
+		"id":   id,
+		"type": event,
+		"time": now,
+		"data": data,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, w := range hooks {
+		if !subscribed(w, event) {
+			continue
+		}
+		err := SaveOutbox(db, OutboxEvent{
+			WebhookID: w.ID,
+			Event:     event,
+			Payload:   string(payload),
+			NextTry:   now,
+			Status:    "pending",
+			CreatedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func intentEvent(db *sql.DB, i Intent) error {
+	switch {
+	case i.Direction == "in" && i.Status == "done":
+		return Emit(db, "payment.received", i)
+	case i.Status == "done" || i.Status == "fail":
+		return Emit(db, "intent."+i.Status, i)
+	}
+	return nil
+}
+
+// DeliverWebhooks posts every due outbox event once. Failures are rescheduled
+// with exponential backoff and marked dead after webhookMaxAttempts.
+func DeliverWebhooks(db *sql.DB) (int, error) {
+	now := time.Now()
+	due, err := DueOutbox(db, now.Unix())
+	if err != nil || len(due) == 0 {
+		return 0, err
+	}
+
+	hooks, err := ListWebhooks(db)
+	if err != nil {
+		return 0, err
+	}
+	byID := map[string]Webhook{}
+	for _, w := range hooks {
+		byID[w.ID] = w
+	}
+
+	client := &http.Client{Timeout: webhookTimeout}
+	delivered := 0
+	for _, e := range due {
+		w, ok := byID[e.WebhookID]
+		if !ok {
+			e.Status = "dead"
+			e.LastError = "webhook removed"
+			SaveOutbox(db, e)
+			continue
+		}
+
+		e.Attempts++
+		if err := post(client, w, e); err != nil {
+			e.LastError = err.Error()
+			if e.Attempts >= webhookMaxAttempts {
+				e.Status = "dead"
+			} else {
+				e.NextTry = now.Add(backoff(e.Attempts)).Unix()
+			}
+		} else {
+			e.Status = "delivered"
+			e.LastError = ""
+			delivered++
+		}
+		if err := SaveOutbox(db, e); err != nil {
+			return delivered, err
+		}
+	}
+	return delivered, nil
+}
+
+func RetryOutbox(db *sql.DB, id int64) error {
+	res, err := db.Exec(`
+		UPDATE outbox SET status = 'pending', attempts = 0, next_try = ? WHERE id = ? AND status = 'dead'
+	`, time.Now().Unix(), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>", sent as
+// X-Dix-Signature alongside X-Dix-Timestamp.
+func SignWebhook(secret string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func post(client *http.Client, w Webhook, e OutboxEvent) error {
+	body := []byte(e.Payload)
+	ts := time.Now().Unix()
+
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dix-webhook")
+	req.Header.Set("X-Dix-Event", e.Event)
+	req.Header.Set("X-Dix-Delivery", strconv.FormatInt(e.ID, 10))
+	req.Header.Set("X-Dix-Timestamp", strconv.FormatInt(ts, 10))
+	req.Header.Set("X-Dix-Signature", SignWebhook(w.Secret, ts, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("http %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func backoff(attempts int) time.Duration {
+	d := webhookBackoff << (attempts - 1)
+	if d <= 0 || d > webhookMaxBackoff {
+		return webhookMaxBackoff
+	}
+	return d
+}
+
+func subscribed(w Webhook, event string) bool {
+	for _, e := range w.Events {
+		if e == "*" || e == event {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(e, ".*"); ok && strings.HasPrefix(event, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func validEvent(e string) bool {
+	if e == "*" {
+		return true
+	}
+	for _, known := range WebhookEvents {
+		if e == known {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(e, ".*"); ok && strings.HasPrefix(known, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func randHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,74 @@
+package dix
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignWebhook(t *testing.T) {
+	got := SignWebhook("secret", 1767225600, []byte(`{"id":"x"}`))
+	if want := "020c14d1288ed13849908a15563ef8c0dd2c86572b15ef551001ffacbab84755"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for _, tc := range []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{5, 8 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{webhookMaxAttempts, time.Hour},
+		{70, time.Hour},
+	} {
+		if got := backoff(tc.attempts); got != tc.want {
+			t.Errorf("attempt %d: got %s, want %s", tc.attempts, got, tc.want)
+		}
+	}
+}
+
+func TestSubscribed(t *testing.T) {
+	for _, tc := range []struct {
+		events []string
+		event  string
+		want   bool
+	}{
+		{[]string{"*"}, "pool.claim", true},
+		{[]string{"intent.done"}, "intent.done", true},
+		{[]string{"intent.done"}, "intent.fail", false},
+		{[]string{"pool.*"}, "pool.claim", true},
+		{[]string{"pool.*"}, "payment.received", false},
+		{[]string{"pool.*"}, "poolx.claim", false},
+		{[]string{"intent.fail", "payment.*"}, "payment.received", true},
+	} {
+		if got := subscribed(Webhook{Events: tc.events}, tc.event); got != tc.want {
+			t.Errorf("%v %s: got %v", tc.events, tc.event, got)
+		}
+	}
+}
+
+func TestIntentEvents(t *testing.T) {
+	_, rpcURL, keypair, db := payFixture(t)
+	if _, err := AddWebhook(db, "https://example.com/hook", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Save(db, Intent{ID: "plain", Token: "usdc", Amount: 1, Time: 1, Status: "done"}); err != nil {
+		t.Fatal(err)
+	}
+	if events, _ := ListOutbox(db, "", 10); len(events) != 0 {
+		t.Fatalf("Save queued %d events", len(events))
+	}
+
+	if err := Pay(db, keypair, "joao", filled(7), SourceContacts, 1_000_000, "usdc", "", nil, rpcURL); err != nil {
+		t.Fatal(err)
+	}
+	events, _ := ListOutbox(db, "", 10)
+	if len(events) != 1 || events[0].Event != "intent.done" {
+		t.Fatalf("got %+v, want one intent.done", events)
+	}
+}